@@ -0,0 +1,49 @@
+package algorithms
+
+import (
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+)
+
+// ConnectedComponents labels every vertex of the graph of each epoch with
+// the smallest vertex name in its connected component, edges being undirected.
+// In every iteration each vertex takes the smallest label among itself and
+// its neighbours, until no label changes. Results are emitted as "vertex label"
+// once the epoch of the edges is complete.
+func ConnectedComponents(edges operators.Operator) operators.EgressOp {
+	return gather(edges).Iterate(func(iteration int, ts timestamp.Timestamp, msgs []*request.Message) (iterator.Iterator[*request.Message], bool, error) {
+		st, err := decodeState(msgs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		labels := make(map[string]string)
+		if iteration == 0 {
+			for _, v := range st.vertices() {
+				labels[v] = v
+			}
+			return iterator.IterFromArray(st.encodeNext(labels)), false, nil
+		}
+
+		changed := false
+		for v, label := range st.values {
+			labels[v] = label
+		}
+		for _, e := range st.edges {
+			if st.values[e.src] < labels[e.dst] {
+				labels[e.dst] = st.values[e.src]
+				changed = true
+			}
+			if st.values[e.dst] < labels[e.src] {
+				labels[e.src] = st.values[e.dst]
+				changed = true
+			}
+		}
+		if !changed {
+			return iterator.IterFromArray(encodeResult(labels)), true, nil
+		}
+		return iterator.IterFromArray(st.encodeNext(labels)), false, nil
+	})
+}
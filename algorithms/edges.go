@@ -0,0 +1,164 @@
+package algorithms
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+)
+
+// Messages flowing through the algorithms are lines of text.
+// Inputs are edges, results are vertices with a value, and the state
+// carried between iterations is tagged so it can be told apart from edges.
+const (
+	tagEdge  = "E"
+	tagState = "S"
+)
+
+// ownerKey is the exchange key of every edge. The loop variable of the algorithms
+// holds the whole graph of an epoch, so all edges must meet on one worker.
+const ownerKey = "graph"
+
+// gather routes the edges read by every worker to the worker owning the graph.
+func gather(edges operators.Operator) operators.Operator {
+	return edges.Exchange(func(msg *request.Message) (string, error) {
+		return ownerKey, nil
+	})
+}
+
+// NewEdge encodes an unweighted edge from src to dst.
+// Vertex names cannot contain spaces.
+func NewEdge(src string, dst string) *request.Message {
+	return NewWeightedEdge(src, dst, 1)
+}
+
+// NewWeightedEdge encodes an edge from src to dst with weight.
+// Vertex names cannot contain spaces.
+func NewWeightedEdge(src string, dst string, weight float64) *request.Message {
+	return request.NewMessage([]byte(fmt.Sprintf("%s %s %s %s", tagEdge, src, dst, formatFloat(weight))))
+}
+
+// ParseResult decodes a result message of the algorithms into
+// the vertex and its value, such as its label, distance or rank.
+func ParseResult(msg *request.Message) (string, string, error) {
+	fields := strings.Fields(msg.ToString())
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("invalid result message: %s", msg.ToString())
+	}
+	return fields[0], fields[1], nil
+}
+
+// ParseFloatResult decodes a result message with a numeric value,
+// such as a distance or a rank.
+func ParseFloatResult(msg *request.Message) (string, float64, error) {
+	v, val, err := ParseResult(msg)
+	if err != nil {
+		return "", 0, err
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return v, f, nil
+}
+
+type edgeItem struct {
+	src    string
+	dst    string
+	weight float64
+}
+
+// iterState is the decoded loop variable of an iteration:
+// the edges of the epoch and the values of the vertices.
+type iterState struct {
+	edges  []edgeItem
+	values map[string]string
+}
+
+func decodeState(msgs []*request.Message) (*iterState, error) {
+	st := &iterState{
+		edges:  []edgeItem{},
+		values: make(map[string]string),
+	}
+	for _, msg := range msgs {
+		fields := strings.Fields(msg.ToString())
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty message")
+		}
+		switch fields[0] {
+		case tagEdge:
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("invalid edge message: %s", msg.ToString())
+			}
+			w, err := strconv.ParseFloat(fields[3], 64)
+			if err != nil {
+				return nil, err
+			}
+			st.edges = append(st.edges, edgeItem{
+				src:    fields[1],
+				dst:    fields[2],
+				weight: w,
+			})
+		case tagState:
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("invalid state message: %s", msg.ToString())
+			}
+			st.values[fields[1]] = fields[2]
+		default:
+			return nil, fmt.Errorf("invalid message: %s", msg.ToString())
+		}
+	}
+	return st, nil
+}
+
+// vertices returns all vertices of the edges in sorted order.
+func (st *iterState) vertices() []string {
+	set := make(map[string]bool)
+	for _, e := range st.edges {
+		set[e.src] = true
+		set[e.dst] = true
+	}
+	res := make([]string, 0, len(set))
+	for v := range set {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// encodeNext encodes the loop variable of the next iteration.
+func (st *iterState) encodeNext(values map[string]string) []*request.Message {
+	msgs := make([]*request.Message, 0, len(st.edges)+len(values))
+	for _, e := range st.edges {
+		msgs = append(msgs, NewWeightedEdge(e.src, e.dst, e.weight))
+	}
+	for _, v := range sortedKeys(values) {
+		msgs = append(msgs, request.NewMessage([]byte(fmt.Sprintf("%s %s %s", tagState, v, values[v]))))
+	}
+	return msgs
+}
+
+// encodeResult encodes the values of vertices as results.
+func encodeResult(values map[string]string) []*request.Message {
+	msgs := make([]*request.Message, 0, len(values))
+	for _, v := range sortedKeys(values) {
+		msgs = append(msgs, request.NewMessage([]byte(fmt.Sprintf("%s %s", v, values[v]))))
+	}
+	return msgs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package algorithms
+
+import (
+	"strconv"
+
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+)
+
+// PageRank computes the rank of every vertex of the directed graph of each epoch
+// with the given number of iterations and damping factor. Ranks sum up to 1, and the
+// rank of vertices without out edges is spread over all vertices. Results are emitted
+// as "vertex rank" once the epoch of the edges is complete.
+func PageRank(edges operators.Operator, iterations int, damping float64) operators.EgressOp {
+	return gather(edges).Iterate(func(iteration int, ts timestamp.Timestamp, msgs []*request.Message) (iterator.Iterator[*request.Message], bool, error) {
+		st, err := decodeState(msgs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		vertices := st.vertices()
+		n := float64(len(vertices))
+		ranks := make(map[string]float64)
+		for _, v := range vertices {
+			ranks[v] = 1 / n
+		}
+		for v, val := range st.values {
+			r, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, false, err
+			}
+			ranks[v] = r
+		}
+
+		if iteration > 0 {
+			outDegrees := make(map[string]int)
+			for _, e := range st.edges {
+				outDegrees[e.src] += 1
+			}
+			dangling := 0.0
+			for _, v := range vertices {
+				if outDegrees[v] == 0 {
+					dangling += ranks[v]
+				}
+			}
+			next := make(map[string]float64)
+			for _, v := range vertices {
+				next[v] = (1-damping)/n + damping*dangling/n
+			}
+			for _, e := range st.edges {
+				next[e.dst] += damping * ranks[e.src] / float64(outDegrees[e.src])
+			}
+			ranks = next
+		}
+
+		values := make(map[string]string)
+		for v, r := range ranks {
+			values[v] = formatFloat(r)
+		}
+		if iteration >= iterations {
+			return iterator.IterFromArray(encodeResult(values)), true, nil
+		}
+		return iterator.IterFromArray(st.encodeNext(values)), false, nil
+	})
+}
@@ -0,0 +1,80 @@
+package algorithms
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+)
+
+// ShortestPaths computes the distances from source to every vertex reachable from it
+// in the directed, weighted graph of each epoch. Each iteration relaxes all edges once,
+// until no distance changes. Results are emitted as "vertex distance" once the epoch
+// of the edges is complete. Weights must not be negative.
+func ShortestPaths(edges operators.Operator, source string) operators.EgressOp {
+	return shortestPaths(edges, source, false)
+}
+
+// BFS computes the number of hops from source to every vertex reachable from it
+// in the directed graph of each epoch, ignoring edge weights. Results are emitted
+// as "vertex hops" once the epoch of the edges is complete.
+func BFS(edges operators.Operator, source string) operators.EgressOp {
+	return shortestPaths(edges, source, true)
+}
+
+func shortestPaths(edges operators.Operator, source string, unit bool) operators.EgressOp {
+	return gather(edges).Iterate(func(iteration int, ts timestamp.Timestamp, msgs []*request.Message) (iterator.Iterator[*request.Message], bool, error) {
+		st, err := decodeState(msgs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		dists := make(map[string]float64)
+		if iteration == 0 {
+			for _, v := range st.vertices() {
+				if v == source {
+					dists[v] = 0
+				}
+			}
+		}
+		for v, val := range st.values {
+			d, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, false, err
+			}
+			dists[v] = d
+		}
+
+		changed := iteration == 0
+		for _, e := range st.edges {
+			srcDist, exist := dists[e.src]
+			if !exist {
+				continue
+			}
+			w := e.weight
+			if unit {
+				w = 1
+			}
+			dstDist, exist := dists[e.dst]
+			if !exist {
+				dstDist = math.Inf(1)
+			}
+			if srcDist+w < dstDist {
+				dists[e.dst] = srcDist + w
+				changed = true
+			}
+		}
+
+		values := make(map[string]string)
+		for v, d := range dists {
+			values[v] = formatFloat(d)
+		}
+		if !changed {
+			return iterator.IterFromArray(encodeResult(values)), true, nil
+		}
+		return iterator.IterFromArray(st.encodeNext(values)), false, nil
+	})
+}
@@ -0,0 +1,352 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/progress"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+// Interval between attempts to connect to a peer which is not listening yet.
+const dialInterval = 50 * time.Millisecond
+
+// Cluster holds the connections of this process to all other processes.
+// Every process dials one data connection to each worker of the other processes,
+// and one progress connection to each process with a lower index.
+type Cluster struct {
+	config   Config
+	listener net.Listener
+
+	mu sync.Mutex
+	// Progress connections by process index.
+	progress map[int]*progress.ConnPeer
+	// Handles to send requests to workers of other processes, by worker index.
+	outbound map[int]*handles.TcpWorkerHandle
+	// Handles receiving requests for workers of this process, by worker index.
+	inbound map[int][]*handles.TcpWorkerHandle
+	// Data connections accepted so far, one per process and worker.
+	accepted map[dataConn]bool
+}
+
+// dataConn identifies the data connection of a process to a worker of this process.
+type dataConn struct {
+	process int
+	worker  int
+}
+
+// Connect listens on the address of this process and connects to all other processes.
+// It returns once all connections are established and checked by handshake.
+func Connect(ctx context.Context, config Config) (*Cluster, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", config.Addresses[config.Process])
+	if err != nil {
+		return nil, err
+	}
+	if config.TLS != nil {
+		listener = tls.NewListener(listener, serverTLSConfig(config.TLS))
+	}
+
+	c := &Cluster{
+		config:   config,
+		listener: listener,
+		progress: make(map[int]*progress.ConnPeer),
+		outbound: make(map[int]*handles.TcpWorkerHandle),
+		inbound:  make(map[int][]*handles.TcpWorkerHandle),
+		accepted: make(map[dataConn]bool),
+	}
+
+	ctx, cancelFunc := context.WithTimeout(ctx, config.Timeout)
+	defer cancelFunc()
+
+	expected := (config.Processes()-1)*config.Workers + config.Processes() - 1 - config.Process
+	acceptErr := make(chan error, 1)
+	go func() {
+		acceptErr <- c.acceptAll(ctx, expected)
+	}()
+	dialErr := make(chan error, 1)
+	go func() {
+		dialErr <- c.dialAll(ctx)
+	}()
+
+	// Wait for both sides, but fail as soon as either of them fails.
+	for i := 0; i < 2; i++ {
+		select {
+		case err = <-acceptErr:
+		case err = <-dialErr:
+		case <-ctx.Done():
+			err = fmt.Errorf("timeout waiting for peers to connect: %w", ctx.Err())
+		}
+		if err != nil {
+			cancelFunc()
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *Cluster) Config() Config {
+	return c.config
+}
+
+// ProgressPeers returns the progress connections to all other processes.
+func (c *Cluster) ProgressPeers() []progress.Peer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := []progress.Peer{}
+	for p := 0; p < c.config.Processes(); p++ {
+		if peer, exist := c.progress[p]; exist {
+			res = append(res, peer)
+		}
+	}
+	return res
+}
+
+// WorkerHandle returns the handle to send requests to a worker of another process.
+func (c *Cluster) WorkerHandle(worker int) (handles.WorkerHandle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, exist := c.outbound[worker]
+	if !exist {
+		return nil, fmt.Errorf("no connection to worker %d", worker)
+	}
+	return h, nil
+}
+
+// Serve forwards requests received from other processes to the handles
+// of local workers, indexed by their index in this process.
+func (c *Cluster) Serve(ctx context.Context, local []handles.WorkerHandle) error {
+	if len(local) != c.config.Workers {
+		return fmt.Errorf("expect %d local worker handles but got %d", c.config.Workers, len(local))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, h := range local {
+		for _, in := range c.inbound[c.config.WorkerIndex(i)] {
+			go forward(ctx, in, h)
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) Close() error {
+	err := c.listener.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.progress {
+		p.Close()
+	}
+	for _, h := range c.outbound {
+		h.Close()
+	}
+	for _, hs := range c.inbound {
+		for _, h := range hs {
+			h.Close()
+		}
+	}
+	return err
+}
+
+func forward(ctx context.Context, in *handles.TcpWorkerHandle, out handles.WorkerHandle) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-in.Done():
+			return
+		case req := <-in.Recv():
+			if err := out.Send(&req); err != nil {
+				utils.Logger().Error(err.Error())
+				return
+			}
+		}
+	}
+}
+
+// acceptResult is the outcome of the handshake on an incoming connection, see accept.
+type acceptResult struct {
+	ok  bool
+	err error
+}
+
+// acceptAll accepts connections until the expected number of peers passed the handshake.
+// Every handshake runs in its own goroutine, so a silent peer does not block other peers from connecting.
+func (c *Cluster) acceptAll(ctx context.Context, expected int) error {
+	results := make(chan acceptResult)
+	listenErr := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := c.listener.Accept()
+			if err != nil {
+				listenErr <- err
+				return
+			}
+			if ctx.Err() != nil {
+				// All peers connected or Connect failed, so nobody waits for more connections.
+				conn.Close()
+				return
+			}
+			go func() {
+				ok, err := c.accept(ctx, conn)
+				select {
+				case results <- acceptResult{ok: ok, err: err}:
+				case <-ctx.Done():
+				}
+			}()
+		}
+	}()
+
+	for n := 0; n < expected; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-listenErr:
+			return err
+		case res := <-results:
+			if res.err != nil {
+				return res.err
+			}
+			if res.ok {
+				n += 1
+			}
+		}
+	}
+	return nil
+}
+
+// accept runs the handshake on an incoming connection. Connections which are not
+// from a process of a cluster are dropped, but a peer with a different
+// version or cluster layout is an error, and so is a connection the peer
+// should not open, either twice or the wrong way round.
+func (c *Cluster) accept(ctx context.Context, conn net.Conn) (bool, error) {
+	conn.SetDeadline(time.Now().Add(c.config.HandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	h, err := acceptHandshake(conn, c.config)
+	if err != nil {
+		utils.Logger().Warn(err.Error())
+		conn.Close()
+		return false, nil
+	}
+	if err := h.check(c.config); err != nil {
+		conn.Close()
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() != nil {
+		conn.Close()
+		return false, nil
+	}
+	process := int(h.process)
+	if h.kind == kindProgress {
+		// Only processes with a higher index dial the progress connection.
+		if process < c.config.Process {
+			conn.Close()
+			return false, fmt.Errorf("process %d opened a progress connection to higher process %d", process, c.config.Process)
+		}
+		if _, exist := c.progress[process]; exist {
+			conn.Close()
+			return false, fmt.Errorf("process %d opened a second progress connection", process)
+		}
+		c.progress[process] = progress.NewConnPeer(process, conn)
+		return true, nil
+	}
+	worker := int(h.worker)
+	if c.config.ProcessOf(worker) != c.config.Process {
+		conn.Close()
+		return false, fmt.Errorf("process %d connected for worker %d not in this process", process, worker)
+	}
+	key := dataConn{process: process, worker: worker}
+	if c.accepted[key] {
+		conn.Close()
+		return false, fmt.Errorf("process %d opened a second data connection for worker %d", process, worker)
+	}
+	c.accepted[key] = true
+	c.inbound[worker] = append(c.inbound[worker], handles.NewTcpWorkerHandle(conn))
+	return true, nil
+}
+
+func (c *Cluster) dialAll(ctx context.Context) error {
+	for p := 0; p < c.config.Processes(); p++ {
+		if p == c.config.Process {
+			continue
+		}
+		for i := 0; i < c.config.Workers; i++ {
+			worker := p*c.config.Workers + i
+			conn, err := c.dial(ctx, p, kindData, worker)
+			if err != nil {
+				return err
+			}
+			c.mu.Lock()
+			c.outbound[worker] = handles.NewTcpWorkerHandle(conn)
+			c.mu.Unlock()
+		}
+		if p < c.config.Process {
+			conn, err := c.dial(ctx, p, kindProgress, 0)
+			if err != nil {
+				return err
+			}
+			c.mu.Lock()
+			c.progress[p] = progress.NewConnPeer(p, conn)
+			c.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// dial connects to a process, retrying until it listens, and runs the handshake.
+func (c *Cluster) dial(ctx context.Context, process int, kind uint8, worker int) (net.Conn, error) {
+	addr := c.config.Addresses[process]
+	var d net.Dialer
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil && c.config.TLS != nil {
+			tlsConn := tls.Client(conn, clientTLSConfig(c.config.TLS, addr))
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("TLS handshake with process %d failed: %w", process, err)
+			}
+			conn = tlsConn
+		}
+		if err == nil {
+			h, err := newHandshake(c.config, kind, worker)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			conn.SetDeadline(time.Now().Add(c.config.HandshakeTimeout))
+			reply, err := dialHandshake(conn, c.config, h)
+			if err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("handshake with process %d failed: %w", process, err)
+			}
+			conn.SetDeadline(time.Time{})
+			if err := reply.check(c.config); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			if int(reply.process) != process {
+				conn.Close()
+				return nil, fmt.Errorf("process %d listens at %s, expect process %d", reply.process, addr, process)
+			}
+			return conn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("cannot connect to process %d at %s: %v", process, addr, err)
+		case <-time.After(dialInterval):
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package cluster
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/progress"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+// freeAddresses returns n loopback addresses nothing listens on.
+func freeAddresses(t *testing.T, n int) []string {
+	res := []string{}
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		res = append(res, l.Addr().String())
+		l.Close()
+	}
+	return res
+}
+
+func testConfig(addresses []string, process int) Config {
+	return Config{
+		Process:          process,
+		Addresses:        addresses,
+		Workers:          2,
+		Timeout:          5 * time.Second,
+		HandshakeTimeout: 5 * time.Second,
+		Progress:         progress.DefaultConfig(),
+	}
+}
+
+func TestConfig(t *testing.T) {
+	c := testConfig([]string{"a", "b", "c"}, 1)
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, 6, c.Peers())
+	assert.Equal(t, 2, c.WorkerIndex(0))
+	assert.Equal(t, 1, c.ProcessOf(3))
+
+	c.Process = 3
+	assert.Error(t, c.Validate())
+	c.Process = 0
+	c.Workers = 0
+	assert.Error(t, c.Validate())
+	c.Workers = 2
+	c.Timeout = 0
+	assert.Error(t, c.Validate())
+	c.Timeout = time.Second
+	c.Progress.HeartbeatInterval = 0
+	assert.Error(t, c.Validate())
+	c.Progress.HeartbeatInterval = c.Progress.FailureTimeout
+	assert.Error(t, c.Validate())
+	c.Progress.FailureTimeout = 0
+	assert.Nil(t, c.Validate())
+}
+
+func TestConnect(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	addresses := freeAddresses(t, 3)
+	clusters := make([]*Cluster, 3)
+	errCh := make(chan error, 3)
+	for p := range addresses {
+		go func(p int) {
+			c, err := Connect(ctx, testConfig(addresses, p))
+			clusters[p] = c
+			errCh <- err
+		}(p)
+	}
+	for range addresses {
+		assert.Nil(t, <-errCh)
+	}
+	for _, c := range clusters {
+		defer c.Close()
+		assert.Equal(t, 2, len(c.ProgressPeers()))
+	}
+
+	_, err := clusters[0].WorkerHandle(1)
+	assert.Error(t, err)
+
+	// Process 0 sends a request to worker 3, which is the second worker of process 1.
+	local := []handles.WorkerHandle{handles.NewSimpleWorkerHandle(), handles.NewSimpleWorkerHandle()}
+	assert.Nil(t, clusters[1].Serve(ctx, local))
+	h, err := clusters[0].WorkerHandle(3)
+	assert.Nil(t, err)
+	err = h.Send(&request.Request{
+		Type: request.Type_SendBy,
+		Edge: edge.NewEdge(1, 2),
+		Msg:  *request.NewMessage([]byte("msg")),
+		Ts:   *timestamp.NewTimestamp(),
+	})
+	assert.Nil(t, err)
+	req := <-local[1].Recv()
+	assert.Equal(t, "msg", req.Msg.ToString())
+}
+
+func TestConnectHandshakeMismatch(t *testing.T) {
+	addresses := freeAddresses(t, 2)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := Connect(context.Background(), testConfig(addresses, 0))
+		errCh <- err
+	}()
+
+	// A peer configured with a different number of workers.
+	other := testConfig(addresses, 1)
+	other.Workers = 3
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", addresses[0])
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+	h, err := newHandshake(other, kindData, 0)
+	assert.Nil(t, err)
+
+	reply, err := dialHandshake(conn, other, h)
+	assert.Nil(t, err)
+	assert.Error(t, reply.check(other))
+	assert.Error(t, <-errCh)
+}
+
+// dialAs connects to the process at addr as the given peer and runs the handshake.
+func dialAs(t *testing.T, addr string, peer Config, kind uint8, worker int) net.Conn {
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", addr)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	h, err := newHandshake(peer, kind, worker)
+	assert.Nil(t, err)
+	_, err = dialHandshake(conn, peer, h)
+	assert.Nil(t, err)
+	return conn
+}
+
+func TestConnectProgressFromLowerProcess(t *testing.T) {
+	addresses := freeAddresses(t, 2)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := Connect(context.Background(), testConfig(addresses, 1))
+		errCh <- err
+	}()
+
+	// Process 0 accepts the progress connection of process 1, it never dials one.
+	conn := dialAs(t, addresses[1], testConfig(addresses, 0), kindProgress, 0)
+	defer conn.Close()
+	err := <-errCh
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "progress connection to higher process")
+}
+
+func TestConnectDuplicateConnection(t *testing.T) {
+	for _, kind := range []uint8{kindProgress, kindData} {
+		addresses := freeAddresses(t, 2)
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := Connect(context.Background(), testConfig(addresses, 0))
+			errCh <- err
+		}()
+
+		// The second connection is not counted as another peer.
+		peer := testConfig(addresses, 1)
+		for i := 0; i < 2; i++ {
+			conn := dialAs(t, addresses[0], peer, kind, 0)
+			defer conn.Close()
+		}
+		err := <-errCh
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "second")
+	}
+}
+
+func TestConnectSilentPeer(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	addresses := freeAddresses(t, 2)
+	errCh := make(chan error, 2)
+	connect := func(p int) {
+		c, err := Connect(ctx, testConfig(addresses, p))
+		if err == nil {
+			defer c.Close()
+		}
+		errCh <- err
+	}
+	go connect(0)
+
+	// A connection which never sends its handshake does not hold back the peers.
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", addresses[0])
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	start := time.Now()
+	go connect(1)
+	for range addresses {
+		assert.Nil(t, <-errCh)
+	}
+	assert.Less(t, time.Since(start), testConfig(addresses, 0).HandshakeTimeout)
+}
+
+func TestHandshakeToken(t *testing.T) {
+	handshakeWith := func(dialerToken string, accepterToken string) (error, error) {
+		dialer := testConfig([]string{"a", "b"}, 1)
+		dialer.Token = dialerToken
+		accepter := testConfig([]string{"a", "b"}, 0)
+		accepter.Token = accepterToken
+
+		dialerConn, accepterConn := net.Pipe()
+		defer dialerConn.Close()
+		defer accepterConn.Close()
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := acceptHandshake(accepterConn, accepter)
+			// Unblock the dialer if it still waits for the accepter.
+			accepterConn.Close()
+			errCh <- err
+		}()
+		h, err := newHandshake(dialer, kindProgress, 0)
+		assert.Nil(t, err)
+		_, dialErr := dialHandshake(dialerConn, dialer, h)
+		dialerConn.Close()
+		return dialErr, <-errCh
+	}
+
+	dialErr, acceptErr := handshakeWith("secret", "secret")
+	assert.Nil(t, dialErr)
+	assert.Nil(t, acceptErr)
+
+	// An accepter without the token cannot prove it knows the token.
+	dialErr, acceptErr = handshakeWith("secret", "wrong")
+	assert.Error(t, dialErr)
+	assert.Error(t, acceptErr)
+
+	// Neither can a dialer.
+	dialErr, acceptErr = handshakeWith("wrong", "secret")
+	assert.Error(t, dialErr)
+	assert.Error(t, acceptErr)
+}
+
+func TestHandshakeVersion(t *testing.T) {
+	config := testConfig([]string{"a", "b"}, 0)
+	h, err := newHandshake(testConfig([]string{"a", "b"}, 1), kindProgress, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, h.check(config))
+
+	h.version += 1
+	assert.Error(t, h.check(config))
+}
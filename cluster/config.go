@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/progress"
+)
+
+// Config describes the processes of a cluster running the same dataflow.
+type Config struct {
+	// Index of this process in Addresses.
+	Process int
+	// Listening addresses of all processes, indexed by process.
+	Addresses []string
+	// Number of workers in each process.
+	Workers int
+	// Time to wait for all peers to connect.
+	Timeout time.Duration
+	// Time to wait for a single peer to complete the handshake once connected.
+	HandshakeTimeout time.Duration
+	// If not nil, connections between processes use mutual TLS with this config,
+	// see LoadTLSConfig.
+	TLS *tls.Config
+	// If not empty, peers must prove they know the same token in the handshake.
+	// The token itself is never sent.
+	Token string
+	// Batching of progress updates and failure detection between processes.
+	Progress progress.Config
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Process:          0,
+		Addresses:        []string{"127.0.0.1:7000"},
+		Workers:          1,
+		Timeout:          30 * time.Second,
+		HandshakeTimeout: 5 * time.Second,
+		Progress:         progress.DefaultConfig(),
+	}
+}
+
+func (c Config) Validate() error {
+	if len(c.Addresses) == 0 {
+		return fmt.Errorf("no process addresses in cluster config")
+	}
+	if c.Process < 0 || c.Process >= len(c.Addresses) {
+		return fmt.Errorf("invalid process index %d with %d processes", c.Process, len(c.Addresses))
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", c.Workers)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("invalid connect timeout: %v", c.Timeout)
+	}
+	if c.HandshakeTimeout <= 0 {
+		return fmt.Errorf("invalid handshake timeout: %v", c.HandshakeTimeout)
+	}
+	if c.Progress.FlushInterval <= 0 {
+		return fmt.Errorf("invalid progress flush interval: %v", c.Progress.FlushInterval)
+	}
+	// Without heartbeats more often than the failure timeout, idle peers look failed.
+	if c.Progress.FailureTimeout > 0 &&
+		(c.Progress.HeartbeatInterval <= 0 || c.Progress.HeartbeatInterval >= c.Progress.FailureTimeout) {
+		return fmt.Errorf(
+			"invalid heartbeat interval %v with failure timeout %v",
+			c.Progress.HeartbeatInterval, c.Progress.FailureTimeout,
+		)
+	}
+	return nil
+}
+
+// Processes returns the number of processes in the cluster.
+func (c Config) Processes() int {
+	return len(c.Addresses)
+}
+
+// Peers returns the number of workers in the cluster.
+func (c Config) Peers() int {
+	return len(c.Addresses) * c.Workers
+}
+
+// WorkerIndex returns the index in the cluster of the local worker with the given index in this process.
+// Workers are numbered process by process, so the owner of a worker is WorkerIndex / Workers.
+func (c Config) WorkerIndex(local int) int {
+	return c.Process*c.Workers + local
+}
+
+// ProcessOf returns the index of the process running the worker.
+func (c Config) ProcessOf(worker int) int {
+	return worker / c.Workers
+}
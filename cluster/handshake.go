@@ -0,0 +1,190 @@
+package cluster
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/stepneko/neko-dataflow/request"
+)
+
+// magic starts every handshake, so connections from something
+// other than a process of the cluster are rejected early.
+const magic uint32 = 0x4e454b4f // "NEKO"
+
+// Kinds of connections between processes.
+const (
+	// Carries progress batches, one connection for each pair of processes.
+	kindProgress uint8 = iota
+	// Carries requests to one worker of the accepting process.
+	kindData
+)
+
+// handshake is exchanged by both ends of a connection before any other data,
+// to make sure both processes run the same version with the same cluster layout.
+type handshake struct {
+	version   uint32
+	kind      uint8
+	process   uint32
+	processes uint32
+	workers   uint32
+	// Index in the cluster of the target worker for data connections.
+	worker uint32
+	// Random challenge the peer must answer with a proof of knowing the token.
+	nonce [nonceSize]byte
+}
+
+const nonceSize = 32
+
+const handshakeSize = 4 + 4 + 1 + 4 + 4 + 4 + 4 + nonceSize
+
+// Labels of the proofs, so a proof sent by one end is never valid as a proof of the other end.
+const (
+	proofDialer   = "neko dialer"
+	proofAccepter = "neko accepter"
+)
+
+func newHandshake(config Config, kind uint8, worker int) (handshake, error) {
+	h := handshake{
+		version:   request.WireVersion,
+		kind:      kind,
+		process:   uint32(config.Process),
+		processes: uint32(config.Processes()),
+		workers:   uint32(config.Workers),
+		worker:    uint32(worker),
+	}
+	if _, err := rand.Read(h.nonce[:]); err != nil {
+		return handshake{}, fmt.Errorf("cannot generate handshake nonce: %w", err)
+	}
+	return h, nil
+}
+
+func (h handshake) encode() []byte {
+	buf := make([]byte, handshakeSize)
+	binary.BigEndian.PutUint32(buf[0:], magic)
+	binary.BigEndian.PutUint32(buf[4:], h.version)
+	buf[8] = h.kind
+	binary.BigEndian.PutUint32(buf[9:], h.process)
+	binary.BigEndian.PutUint32(buf[13:], h.processes)
+	binary.BigEndian.PutUint32(buf[17:], h.workers)
+	binary.BigEndian.PutUint32(buf[21:], h.worker)
+	copy(buf[25:], h.nonce[:])
+	return buf
+}
+
+func writeHandshake(w io.Writer, h handshake) error {
+	_, err := w.Write(h.encode())
+	return err
+}
+
+func readHandshake(r io.Reader) (handshake, error) {
+	buf := make([]byte, handshakeSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return handshake{}, fmt.Errorf("cannot read handshake: %w", err)
+	}
+	if m := binary.BigEndian.Uint32(buf[0:]); m != magic {
+		return handshake{}, fmt.Errorf("invalid handshake magic %x", m)
+	}
+	h := handshake{
+		version:   binary.BigEndian.Uint32(buf[4:]),
+		kind:      buf[8],
+		process:   binary.BigEndian.Uint32(buf[9:]),
+		processes: binary.BigEndian.Uint32(buf[13:]),
+		workers:   binary.BigEndian.Uint32(buf[17:]),
+		worker:    binary.BigEndian.Uint32(buf[21:]),
+	}
+	copy(h.nonce[:], buf[25:])
+	return h, nil
+}
+
+// proof returns the HMAC with the token of both handshakes, which includes the nonce
+// of the peer, so it cannot be replayed, and the layout of both ends, so it cannot be altered.
+// The token itself is never sent.
+func proof(token string, label string, dialer handshake, accepter handshake) []byte {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(label))
+	mac.Write(dialer.encode())
+	mac.Write(accepter.encode())
+	return mac.Sum(nil)
+}
+
+func readProof(r io.Reader) ([]byte, error) {
+	buf := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("cannot read handshake proof: %w", err)
+	}
+	return buf, nil
+}
+
+// dialHandshake runs the handshake of the end which dialed the connection.
+// It sends h, receives the handshake of the peer with its proof of knowing the token,
+// and only then proves it knows the token in turn. It returns the handshake of the peer.
+func dialHandshake(rw io.ReadWriter, config Config, h handshake) (handshake, error) {
+	if err := writeHandshake(rw, h); err != nil {
+		return handshake{}, err
+	}
+	reply, err := readHandshake(rw)
+	if err != nil {
+		return handshake{}, err
+	}
+	p, err := readProof(rw)
+	if err != nil {
+		return handshake{}, err
+	}
+	if !hmac.Equal(p, proof(config.Token, proofAccepter, h, reply)) {
+		return handshake{}, fmt.Errorf("invalid token from process %d", reply.process)
+	}
+	if _, err := rw.Write(proof(config.Token, proofDialer, h, reply)); err != nil {
+		return handshake{}, err
+	}
+	return reply, nil
+}
+
+// acceptHandshake runs the handshake of the end which accepted the connection.
+// It answers the handshake of the peer with its own and a proof of knowing the token,
+// and returns the handshake of the peer once the peer proved it knows the token in turn.
+func acceptHandshake(rw io.ReadWriter, config Config) (handshake, error) {
+	h, err := readHandshake(rw)
+	if err != nil {
+		return handshake{}, err
+	}
+	reply, err := newHandshake(config, h.kind, int(h.worker))
+	if err != nil {
+		return handshake{}, err
+	}
+	buf := append(reply.encode(), proof(config.Token, proofAccepter, h, reply)...)
+	if _, err := rw.Write(buf); err != nil {
+		return handshake{}, err
+	}
+	p, err := readProof(rw)
+	if err != nil {
+		return handshake{}, err
+	}
+	if !hmac.Equal(p, proof(config.Token, proofDialer, h, reply)) {
+		return handshake{}, fmt.Errorf("invalid token from process %d", h.process)
+	}
+	return h, nil
+}
+
+// check validates the handshake of a peer against the local config.
+func (h handshake) check(config Config) error {
+	if h.version != request.WireVersion {
+		return fmt.Errorf("peer process %d runs wire version %d, expect %d", h.process, h.version, request.WireVersion)
+	}
+	if int(h.processes) != config.Processes() || int(h.workers) != config.Workers {
+		return fmt.Errorf(
+			"peer process %d has %d processes with %d workers each, expect %d processes with %d workers each",
+			h.process, h.processes, h.workers, config.Processes(), config.Workers,
+		)
+	}
+	if int(h.process) >= config.Processes() || int(h.process) == config.Process {
+		return fmt.Errorf("invalid peer process index %d", h.process)
+	}
+	if h.kind != kindProgress && h.kind != kindData {
+		return fmt.Errorf("invalid connection kind %d from process %d", h.kind, h.process)
+	}
+	return nil
+}
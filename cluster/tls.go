@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// LoadTLSConfig loads the certificate and key of this process, and the certificate
+// of the authority which signed the certificates of all processes.
+// Every process both presents its certificate and verifies the certificate of its peer.
+// Certificates must be valid for the hosts in the cluster addresses.
+func LoadTLSConfig(certFile string, keyFile string, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	caPem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// serverTLSConfig requires peers to present a certificate.
+func serverTLSConfig(config *tls.Config) *tls.Config {
+	res := config.Clone()
+	res.ClientAuth = tls.RequireAndVerifyClientCert
+	return res
+}
+
+// clientTLSConfig verifies the certificate of the peer against the host it dials.
+func clientTLSConfig(config *tls.Config, addr string) *tls.Config {
+	res := config.Clone()
+	if res.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil {
+			res.ServerName = host
+		}
+	}
+	return res
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCerts generates a self-signed authority and a certificate for localhost signed by it,
+// and writes them to dir. It returns the paths of the certificate, the key and the authority.
+func writeCerts(t *testing.T, dir string) (string, string, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "neko-dataflow test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.Nil(t, err)
+	ca, err := x509.ParseCertificate(caDer)
+	assert.Nil(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "neko-dataflow test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	write := func(name string, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600)
+		assert.Nil(t, err)
+		return path
+	}
+	return write("cert.pem", "CERTIFICATE", der),
+		write("key.pem", "EC PRIVATE KEY", keyDer),
+		write("ca.pem", "CERTIFICATE", caDer)
+}
+
+func TestConnectTLS(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	tlsConfig, err := LoadTLSConfig(writeCerts(t, t.TempDir()))
+	assert.Nil(t, err)
+
+	addresses := freeAddresses(t, 2)
+	errCh := make(chan error, 2)
+	connect := func(p int) {
+		config := testConfig(addresses, p)
+		config.TLS = tlsConfig
+		config.Token = "secret"
+		c, err := Connect(ctx, config)
+		if err == nil {
+			defer c.Close()
+		}
+		errCh <- err
+	}
+	go connect(0)
+
+	// A connection without TLS is dropped while process 0 waits for its peers.
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		conn, err = net.Dial("tcp", addresses[0])
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	h, err := newHandshake(testConfig(addresses, 1), kindProgress, 0)
+	assert.Nil(t, err)
+	_, err = dialHandshake(conn, testConfig(addresses, 1), h)
+	assert.Error(t, err)
+	conn.Close()
+
+	go connect(1)
+	for range addresses {
+		assert.Nil(t, <-errCh)
+	}
+}
+
+func TestConnectTLSUnknownAuthority(t *testing.T) {
+	tlsConfig0, err := LoadTLSConfig(writeCerts(t, t.TempDir()))
+	assert.Nil(t, err)
+	tlsConfig1, err := LoadTLSConfig(writeCerts(t, t.TempDir()))
+	assert.Nil(t, err)
+
+	addresses := freeAddresses(t, 2)
+	config0 := testConfig(addresses, 0)
+	config0.TLS = tlsConfig0
+	config0.Timeout = time.Second
+	go Connect(context.Background(), config0)
+
+	config1 := testConfig(addresses, 1)
+	config1.TLS = tlsConfig1
+	config1.Timeout = time.Second
+	_, err = Connect(context.Background(), config1)
+	assert.Error(t, err)
+}
+
+func TestConnectToken(t *testing.T) {
+	addresses := freeAddresses(t, 2)
+	config0 := testConfig(addresses, 0)
+	config0.Token = "secret"
+	config0.Timeout = time.Second
+	go Connect(context.Background(), config0)
+
+	config1 := testConfig(addresses, 1)
+	config1.Token = "wrong"
+	config1.Timeout = time.Second
+	_, err := Connect(context.Background(), config1)
+	assert.Error(t, err)
+}
+
+func TestLoadTLSConfigMissingFile(t *testing.T) {
+	_, err := LoadTLSConfig("no-cert.pem", "no-key.pem", "no-ca.pem")
+	assert.Error(t, err)
+}
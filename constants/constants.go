@@ -1,3 +1,6 @@
 package constants
 
 const ChanCapacity int = 1024
+
+// MaxFrameSize is the largest encoded request accepted from a network connection.
+const MaxFrameSize int = 64 * 1024 * 1024
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// Kind is the kind of an event.
+type Kind int
+
+const (
+	// A vertex is registered in the dataflow.
+	Kind_OperatorCreated Kind = iota
+	// An edge is registered in the dataflow.
+	Kind_EdgeCreated
+	// A vertex is merged into its upstream by fusion. Src is the vertex it is merged into.
+	Kind_OperatorFused
+	// The worker routes a message along an edge.
+	Kind_MessageSent
+	// A vertex takes a message from its channel.
+	Kind_MessageReceived
+	// The occurrence count of a pointstamp is incremented.
+	Kind_PointstampIncremented
+	// The occurrence count of a pointstamp is decremented.
+	Kind_PointstampDecremented
+	// The worker delivers a notification to a vertex.
+	Kind_NotificationDelivered
+)
+
+var kindNames = map[Kind]string{
+	Kind_OperatorCreated:       "OperatorCreated",
+	Kind_EdgeCreated:           "EdgeCreated",
+	Kind_OperatorFused:         "OperatorFused",
+	Kind_MessageSent:           "MessageSent",
+	Kind_MessageReceived:       "MessageReceived",
+	Kind_PointstampIncremented: "PointstampIncremented",
+	Kind_PointstampDecremented: "PointstampDecremented",
+	Kind_NotificationDelivered: "NotificationDelivered",
+}
+
+func (k Kind) String() string {
+	if name, exist := kindNames[k]; exist {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	if _, exist := kindNames[k]; !exist {
+		return nil, fmt.Errorf("invalid event kind with value: %d", k)
+	}
+	return []byte(k.String()), nil
+}
+
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid event kind: %s", text)
+}
+
+// Event is an entry of the event log. Fields which do not apply to the kind are left empty.
+// Pointstamps on a vertex have the vertex as both Src and Target.
+type Event struct {
+	Kind   Kind      `json:"kind"`
+	Time   time.Time `json:"time"`
+	Worker int       `json:"worker"`
+	// The vertex the event happens at, and its type and name for OperatorCreated.
+	Vertex vertex.Id `json:"vertex,omitempty"`
+	Type   string    `json:"type,omitempty"`
+	Name   string    `json:"name,omitempty"`
+	// The edge of the event.
+	Src    vertex.Id            `json:"src,omitempty"`
+	Target vertex.Id            `json:"target,omitempty"`
+	Ts     *timestamp.Timestamp `json:"ts,omitempty"`
+}
+
+// Subscriber receives the events of a log. It is called in the goroutine emitting the event,
+// so it has to be safe for concurrent use and should return quickly.
+type Subscriber func(e Event)
+
+// Log dispatches events of the workers and operators of a process to its subscribers.
+// All methods can be called on a nil log, which drops all events.
+type Log struct {
+	mu          sync.RWMutex
+	subscribers []Subscriber
+}
+
+func NewLog() *Log {
+	return &Log{
+		subscribers: []Subscriber{},
+	}
+}
+
+// Subscribe adds a subscriber which receives all events emitted from now on.
+func (l *Log) Subscribe(s Subscriber) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, s)
+}
+
+// Enabled tells whether anyone receives events, so that callers can skip building them.
+func (l *Log) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.subscribers) > 0
+}
+
+// Emit sends the event to all subscribers, stamping it with the current time if it has none.
+func (l *Log) Emit(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, s := range l.subscribers {
+		s(e)
+	}
+}
+
+// JSONWriter writes events as JSON lines, one event per line.
+// Its Write method can be used as a Subscriber.
+type JSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Write writes the event. After the first error all events are dropped,
+// the error is returned by Err.
+func (jw *JSONWriter) Write(e Event) {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+	if jw.err != nil {
+		return
+	}
+	jw.err = jw.enc.Encode(&e)
+}
+
+// Err returns the first error of writing events.
+func (jw *JSONWriter) Err() error {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+	return jw.err
+}
+
+// ReadJSON reads all events written by a JSONWriter.
+func ReadJSON(r io.Reader) ([]Event, error) {
+	dec := json.NewDecoder(r)
+	res := []Event{}
+	for {
+		var e Event
+		if err := dec.Decode(&e); err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+}
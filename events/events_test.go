@@ -0,0 +1,57 @@
+package events
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	l := NewLog()
+	assert.False(t, l.Enabled())
+
+	received := []Event{}
+	l.Subscribe(func(e Event) {
+		received = append(received, e)
+	})
+	assert.True(t, l.Enabled())
+
+	l.Emit(Event{Kind: Kind_OperatorCreated, Vertex: 1, Type: "Input"})
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, Kind_OperatorCreated, received[0].Kind)
+	assert.False(t, received[0].Time.IsZero())
+
+	var nilLog *Log
+	assert.False(t, nilLog.Enabled())
+	nilLog.Emit(Event{Kind: Kind_OperatorCreated})
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	jw := NewJSONWriter(&buf)
+	l := NewLog()
+	l.Subscribe(jw.Write)
+
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	l.Emit(Event{Kind: Kind_EdgeCreated, Time: now, Worker: 1, Src: 1, Target: 2})
+	l.Emit(Event{Kind: Kind_MessageSent, Time: now, Src: 1, Target: 2, Ts: timestamp.NewTimestampWithParams(1, []int{0, 2})})
+	assert.Nil(t, jw.Err())
+
+	assert.Equal(t, `{"kind":"EdgeCreated","time":"2022-07-01T00:00:00Z","worker":1,"src":1,"target":2}
+{"kind":"MessageSent","time":"2022-07-01T00:00:00Z","worker":0,"src":1,"target":2,"ts":{"epoch":1,"counters":[0,2]}}
+`, buf.String())
+
+	res, err := ReadJSON(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, Kind_EdgeCreated, res[0].Kind)
+	assert.Equal(t, Kind_MessageSent, res[1].Kind)
+	assert.Equal(t, []int{0, 2}, res[1].Ts.Counters)
+	assert.True(t, now.Equal(res[1].Time))
+
+	_, err = ReadJSON(bytes.NewBufferString(`{"kind":"Unknown"}`))
+	assert.Error(t, err)
+}
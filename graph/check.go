@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvariant is wrapped by all errors reporting counts of the active pointstamps
+// which diverged from the counts recomputed from scratch.
+var ErrInvariant = errors.New("progress tracking invariant violated")
+
+// describePs returns the location and timestamp of the pointstamp for errors.
+func describePs(ps Pointstamp) string {
+	if ps.GetSrc() == ps.GetTarget() {
+		return fmt.Sprintf("vertex %d at %s", ps.GetSrc(), ps.GetTimestamp().ToString())
+	}
+	return fmt.Sprintf("edge %d->%d at %s", ps.GetSrc(), ps.GetTarget(), ps.GetTimestamp().ToString())
+}
+
+// precursors returns the number of active pointstamps other than ps which could-result-in ps.
+func (g *Graph) precursors(ps Pointstamp) (int, error) {
+	n := 0
+	for psHash, psCounter := range g.ActivePsMap {
+		if psHash == ps.Hash() {
+			continue
+		}
+		res, err := g.CouldResultIn(psCounter.PS, ps)
+		if err != nil {
+			return 0, err
+		}
+		if res {
+			n += 1
+		}
+	}
+	return n, nil
+}
+
+// Check verifies the counts of all active pointstamps. Occurrence counts must not be zero,
+// as the pointstamp would have left the active set, nor negative unless allowNegative,
+// as updates from other processes may arrive out of order. Precursor counts must match
+// the counts recomputed from scratch. Every check traverses the graph once for each pair
+// of active pointstamps, so it is only meant for debugging.
+func (g *Graph) Check(allowNegative bool) error {
+	for _, psCounter := range g.ActivePsMap {
+		ps := psCounter.PS
+		if psCounter.OC == 0 || (psCounter.OC < 0 && !allowNegative) {
+			return fmt.Errorf("%w: pointstamp %s is active with occurrence count %d",
+				ErrInvariant, describePs(ps), psCounter.OC)
+		}
+		pc, err := g.precursors(ps)
+		if err != nil {
+			return err
+		}
+		if pc != psCounter.PC {
+			return fmt.Errorf("%w: pointstamp %s has precursor count %d but %d active pointstamps could result in it",
+				ErrInvariant, describePs(ps), psCounter.PC, pc)
+		}
+	}
+	return nil
+}
+
+// CheckFrontier verifies that the active pointstamp is in the frontier
+// if and only if no other active pointstamp could-result-in it.
+func (g *Graph) CheckFrontier(ps Pointstamp, inFrontier bool) error {
+	pc, err := g.precursors(ps)
+	if err != nil {
+		return err
+	}
+	if inFrontier != (pc == 0) {
+		return fmt.Errorf("%w: pointstamp %s is in frontier %t but %d active pointstamps could result in it",
+			ErrInvariant, describePs(ps), inFrontier, pc)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Export is the dataflow graph as written by ExportJSON.
+type Export struct {
+	Vertices []VertexInfo `json:"vertices"`
+	Edges    []EdgeInfo   `json:"edges"`
+}
+
+// ExportJSON writes all vertices and edges of the graph as JSON.
+func (g *Graph) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&Export{
+		Vertices: g.Vertices(),
+		Edges:    g.Edges(),
+	})
+}
+
+// ExportDOT writes the graph in the DOT language of Graphviz. Vertices are labeled
+// with their id and type, and their name if any, and the vertices of each loop scope are drawn in a cluster
+// nested in the cluster of its parent scope. Edges into a port other than 0 are labeled with the port.
+func (g *Graph) ExportDOT(w io.Writer) error {
+	vertices := g.Vertices()
+
+	// Group vertices by scope, and collect all scopes including the ones
+	// only containing other scopes.
+	byScope := make(map[string][]VertexInfo)
+	scopes := make(map[string]bool)
+	for _, v := range vertices {
+		byScope[v.Scope] = append(byScope[v.Scope], v)
+		for path := v.Scope; path != ""; path = parentScope(path) {
+			scopes[path] = true
+		}
+	}
+	children := make(map[string][]string)
+	for path := range scopes {
+		parent := parentScope(path)
+		children[parent] = append(children[parent], path)
+	}
+	for _, paths := range children {
+		sort.Strings(paths)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph dataflow {")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+	writeDOTScope(bw, "", 1, byScope, children)
+	for _, e := range g.Edges() {
+		if e.Port != 0 {
+			fmt.Fprintf(bw, "\t%d -> %d [label=%s];\n", e.Src, e.Target, quoteDOT(fmt.Sprintf("port %d", e.Port)))
+		} else {
+			fmt.Fprintf(bw, "\t%d -> %d;\n", e.Src, e.Target)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// writeDOTScope writes the vertices of a scope and the clusters of its nested scopes.
+func writeDOTScope(
+	bw *bufio.Writer,
+	path string,
+	depth int,
+	byScope map[string][]VertexInfo,
+	children map[string][]string,
+) {
+	indent := strings.Repeat("\t", depth)
+	for _, v := range byScope[path] {
+		label := fmt.Sprintf("%d: %s", v.Id, v.Type)
+		if v.Name != "" {
+			label = fmt.Sprintf("%d: %s (%s)", v.Id, v.Name, v.Type)
+		}
+		fmt.Fprintf(bw, "%s%d [label=%s];\n", indent, v.Id, quoteDOT(label))
+	}
+	for _, child := range children[path] {
+		fmt.Fprintf(bw, "%ssubgraph %s {\n", indent, quoteDOT("cluster_"+child))
+		fmt.Fprintf(bw, "%s\tlabel=%s;\n", indent, quoteDOT(child[strings.LastIndex(child, "/")+1:]))
+		writeDOTScope(bw, child, depth+1, byScope, children)
+		fmt.Fprintf(bw, "%s}\n", indent)
+	}
+}
+
+// parentScope returns the path of the scope a scope is nested in.
+func parentScope(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+var dotEscaper = strings.NewReplacer("\\", `\\`, "\"", `\"`, "\n", `\n`)
+
+func quoteDOT(s string) string {
+	return "\"" + dotEscaper.Replace(s) + "\""
+}
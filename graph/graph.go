@@ -13,6 +13,14 @@ type Node struct {
 	Vid      vertex.Id
 	Type     vertex.Type
 	Children map[*Node]bool
+	// Path of the loop scopes the vertex is nested in, see scope.Path.
+	// It only describes the vertex and does not affect progress tracking.
+	Scope string
+	// Input port of the vertex each upstream vertex sends to,
+	// only kept for vertices with more than one input.
+	InPorts map[vertex.Id]int
+	// Name given to the operator of the vertex, empty if none.
+	Name string
 }
 
 func NewNode(vid vertex.Id, typ vertex.Type) *Node {
@@ -20,9 +28,18 @@ func NewNode(vid vertex.Id, typ vertex.Type) *Node {
 		Vid:      vid,
 		Type:     typ,
 		Children: make(map[*Node]bool),
+		InPorts:  make(map[vertex.Id]int),
 	}
 }
 
+// describe returns the type and id of the vertex, followed by its name if any.
+func (n *Node) describe() string {
+	if n.Name != "" {
+		return fmt.Sprintf("%s %d (%s)", n.Type, n.Vid, n.Name)
+	}
+	return fmt.Sprintf("%s %d", n.Type, n.Vid)
+}
+
 type Graph struct {
 	// A quick look up table to find Nodes in the graph
 	// given input vertex
@@ -50,6 +67,11 @@ func (g *Graph) InsertVertex(vid vertex.Id, typ vertex.Type) {
 }
 
 func (g *Graph) InsertEdge(e edge.Edge) error {
+	return g.InsertEdgeToPort(e, 0)
+}
+
+// InsertEdgeToPort inserts an edge going into the given input port of its target.
+func (g *Graph) InsertEdgeToPort(e edge.Edge, port int) error {
 	src := e.GetSrc()
 	srcNode, exist := g.VertexMap[src]
 	if !exist {
@@ -63,47 +85,77 @@ func (g *Graph) InsertEdge(e edge.Edge) error {
 	}
 
 	srcNode.Children[targetNode] = true
+	if port != 0 {
+		targetNode.InPorts[src] = port
+	}
 
 	return nil
 }
 
-// When a pointstamp p becomes active, the scheduler initializes its precursor count
-// to the number of existing active pointstamps that could-result- in p.
-// At the same time, the scheduler increments the precursor count of any pointstamp that p could-result-in.
-func (g *Graph) IncreOC(ps Pointstamp) error {
-	psHash := ps.Hash()
-	if _, exist := g.ActivePsMap[psHash]; !exist {
-		psCounter := &PointstampCounter{
-			PS: ps,
-			OC: 0,
-			PC: 0,
-		}
-		for currPsHash := range g.ActivePsMap {
-			currPs := g.ActivePsMap[currPsHash].PS
-			res, err := g.CouldResultIn(currPs, ps)
-			if err != nil {
-				return err
-			}
-			if res {
-				psCounter.PC += 1
-			}
+// SetScope sets the path of the loop scopes the vertex is nested in.
+func (g *Graph) SetScope(vid vertex.Id, scope string) error {
+	node, exist := g.VertexMap[vid]
+	if !exist {
+		return fmt.Errorf("vertex not registered with id: %d", vid)
+	}
+	node.Scope = scope
+	return nil
+}
+
+// SetName sets the name of the operator of a vertex.
+func (g *Graph) SetName(vid vertex.Id, name string) error {
+	node, exist := g.VertexMap[vid]
+	if !exist {
+		return fmt.Errorf("vertex not registered with id: %d", vid)
+	}
+	node.Name = name
+	return nil
+}
+
+// Fuse merges vertex next into vertex head, which is its only upstream.
+// Head takes the type of the fused vertex and the edges going out of next.
+// Merging a vertex which is not in the graph does nothing.
+func (g *Graph) Fuse(head vertex.Id, next vertex.Id, typ vertex.Type) error {
+	headNode, exist := g.VertexMap[head]
+	if !exist {
+		return fmt.Errorf("head vertex not registered with id: %d", head)
+	}
+	nextNode, exist := g.VertexMap[next]
+	if !exist {
+		return nil
+	}
+	for _, node := range g.VertexMap {
+		if node != headNode && node.Children[nextNode] {
+			return fmt.Errorf("%s has another upstream than %s", nextNode.describe(), headNode.describe())
 		}
-		for currPsHash := range g.ActivePsMap {
-			currPs := g.ActivePsMap[currPsHash].PS
-			res, err := g.CouldResultIn(ps, currPs)
-			if err != nil {
-				return err
-			}
-			if res {
-				g.ActivePsMap[currPsHash].PC += 1
-			}
+	}
+	headNode.Type = typ
+	headNode.Children = nextNode.Children
+	for child := range nextNode.Children {
+		if port, exist := child.InPorts[next]; exist {
+			child.InPorts[head] = port
+			delete(child.InPorts, next)
 		}
-		g.ActivePsMap[psHash] = psCounter
 	}
-	g.ActivePsMap[psHash].OC += 1
+	delete(g.VertexMap, next)
 	return nil
 }
 
+// Update is a change of the occurrence count of a pointstamp.
+type Update struct {
+	Ps    Pointstamp
+	Delta int
+}
+
+// When a pointstamp p becomes active, the scheduler initializes its precursor count
+// to the number of existing active pointstamps that could-result- in p.
+// At the same time, the scheduler increments the precursor count of any pointstamp that p could-result-in.
+// The count may already be negative if a decrement from another process arrived first,
+// so the increment goes through UpdateOC, which deactivates the pointstamp once it is back at zero.
+func (g *Graph) IncreOC(ps Pointstamp) error {
+	return g.UpdateOC(ps, 1)
+}
+
 // A pointstamp p leaves the active set when its occurrence count drops to zero,
 // at which point the scheduler decrements the precursor count for any pointstamp that p could-result-in.
 // When an active pointstamp p’s precursor count is zero, there is no other pointstamp in the active set
@@ -117,16 +169,74 @@ func (g *Graph) DecreOC(ps Pointstamp) error {
 	}
 	g.ActivePsMap[psHash].OC -= 1
 	if g.ActivePsMap[psHash].OC == 0 {
-		delete(g.ActivePsMap, psHash)
-		for currPsHash := range g.ActivePsMap {
-			currPs := g.ActivePsMap[currPsHash].PS
-			res, err := g.CouldResultIn(ps, currPs)
-			if err != nil {
-				return err
-			}
-			if res {
-				g.ActivePsMap[currPsHash].PC -= 1
-			}
+		return g.deactivate(ps)
+	}
+	return nil
+}
+
+// UpdateOC changes the occurrence count of a pointstamp by delta.
+// Updates from different processes may arrive in any order, so the count
+// may be transiently negative. A pointstamp is active as long as its count is not zero,
+// which keeps the frontier from moving past it until the matching updates arrive.
+func (g *Graph) UpdateOC(ps Pointstamp, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	psHash := ps.Hash()
+	if _, exist := g.ActivePsMap[psHash]; !exist {
+		if err := g.activate(ps); err != nil {
+			return err
+		}
+	}
+	g.ActivePsMap[psHash].OC += delta
+	if g.ActivePsMap[psHash].OC == 0 {
+		return g.deactivate(ps)
+	}
+	return nil
+}
+
+// activate adds the pointstamp to the active set with an occurrence count of zero.
+func (g *Graph) activate(ps Pointstamp) error {
+	psCounter := &PointstampCounter{
+		PS: ps,
+		OC: 0,
+		PC: 0,
+	}
+	for currPsHash := range g.ActivePsMap {
+		currPs := g.ActivePsMap[currPsHash].PS
+		res, err := g.CouldResultIn(currPs, ps)
+		if err != nil {
+			return err
+		}
+		if res {
+			psCounter.PC += 1
+		}
+	}
+	for currPsHash := range g.ActivePsMap {
+		currPs := g.ActivePsMap[currPsHash].PS
+		res, err := g.CouldResultIn(ps, currPs)
+		if err != nil {
+			return err
+		}
+		if res {
+			g.ActivePsMap[currPsHash].PC += 1
+		}
+	}
+	g.ActivePsMap[ps.Hash()] = psCounter
+	return nil
+}
+
+// deactivate removes the pointstamp from the active set.
+func (g *Graph) deactivate(ps Pointstamp) error {
+	delete(g.ActivePsMap, ps.Hash())
+	for currPsHash := range g.ActivePsMap {
+		currPs := g.ActivePsMap[currPsHash].PS
+		res, err := g.CouldResultIn(ps, currPs)
+		if err != nil {
+			return err
+		}
+		if res {
+			g.ActivePsMap[currPsHash].PC -= 1
 		}
 	}
 	return nil
@@ -1,6 +1,10 @@
 package graph
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -316,3 +320,181 @@ func TestIncreOCAndDecreOC(t *testing.T) {
 	_, exist = g.ActivePsMap[ps5.Hash()]
 	assert.Equal(t, exist, false)
 }
+
+func TestUpdateOC(t *testing.T) {
+	g := NewGraph()
+	BuildGraph(t, g)
+
+	ts := timestamp.NewTimestamp()
+	v1 := NewVertexPointStamp(1, ts)
+	v7 := NewVertexPointStamp(7, ts)
+	assert.Nil(t, g.UpdateOC(v7, 1))
+
+	// The decrement from one process arrives before the increment from another.
+	// The pointstamp must stay active, holding back the frontier.
+	assert.Nil(t, g.UpdateOC(v1, -1))
+	assert.Equal(t, -1, g.ActivePsMap[v1.Hash()].OC)
+	assert.Equal(t, 1, g.ActivePsMap[v7.Hash()].PC)
+
+	assert.Nil(t, g.UpdateOC(v1, 1))
+	_, exist := g.ActivePsMap[v1.Hash()]
+	assert.False(t, exist)
+	assert.Equal(t, 0, g.ActivePsMap[v7.Hash()].PC)
+
+	assert.Nil(t, g.UpdateOC(v7, 0))
+	assert.Nil(t, g.UpdateOC(v7, -1))
+	assert.Equal(t, 0, len(g.ActivePsMap))
+}
+
+func TestTrackerIncreOCAfterDecrement(t *testing.T) {
+	tr := NewTracker(1)
+	BuildGraph(t, tr.graph)
+
+	// The decrement broadcast by another process arrives before the local increment.
+	ps := NewVertexPointStamp(1, timestamp.NewTimestamp())
+	assert.Nil(t, tr.Apply([]Update{{Ps: ps, Delta: -1}}))
+	assert.Equal(t, 1, tr.ActivePointstamps())
+
+	ch := tr.Subscribe()
+	assert.Nil(t, tr.IncreOC(ps))
+	assert.Equal(t, 0, tr.ActivePointstamps())
+	select {
+	case <-ch:
+	default:
+		t.Fatal("workers not signaled of the moved frontier")
+	}
+}
+
+func TestFuse(t *testing.T) {
+	g := NewGraph()
+	BuildGraph(t, g)
+
+	assert.Nil(t, g.Fuse(3, 4, vertex.Type_Fused))
+	_, exist := g.VertexMap[4]
+	assert.False(t, exist)
+	assert.Equal(t, vertex.Type_Fused, g.VertexMap[3].Type)
+	assert.Equal(t, 2, len(g.VertexMap[3].Children))
+	assert.True(t, g.VertexMap[3].Children[g.VertexMap[5]])
+	assert.True(t, g.VertexMap[3].Children[g.VertexMap[6]])
+
+	// Fusing again, as another worker does, changes nothing.
+	assert.Nil(t, g.Fuse(3, 4, vertex.Type_Fused))
+	assert.Equal(t, 2, len(g.VertexMap[3].Children))
+
+	// The fused vertex keeps the loop, so progress inside it is still tracked.
+	ts := timestamp.NewTimestampWithParams(0, []int{0})
+	res, err := g.CouldResultIn(NewEdgePointStamp(edge.NewEdge(2, 3), ts), NewVertexPointStamp(3, timestamp.NewTimestampWithParams(0, []int{1})))
+	assert.Nil(t, err)
+	assert.True(t, res)
+
+	// Vertex 3 also receives from the feedback vertex.
+	assert.Error(t, g.Fuse(2, 3, vertex.Type_Fused))
+}
+
+func TestExport(t *testing.T) {
+	g := NewGraph()
+	BuildGraph(t, g)
+	for _, vid := range []vertex.Id{2, 3, 4, 5} {
+		assert.Nil(t, g.SetScope(vid, "loop2"))
+	}
+	assert.Nil(t, g.InsertEdgeToPort(edge.NewEdge(5, 3), 1))
+	assert.Nil(t, g.SetName(7, "sink"))
+
+	var buf bytes.Buffer
+	assert.Nil(t, g.ExportDOT(&buf))
+	assert.Equal(t, `digraph dataflow {
+	node [shape=box];
+	1 [label="1: Input"];
+	6 [label="6: Egress"];
+	7 [label="7: sink (Inspect)"];
+	subgraph "cluster_loop2" {
+		label="loop2";
+		2 [label="2: Ingress"];
+		3 [label="3: Inspect"];
+		4 [label="4: Inspect"];
+		5 [label="5: Feedback"];
+	}
+	1 -> 2;
+	2 -> 3;
+	3 -> 4;
+	4 -> 5;
+	4 -> 6;
+	5 -> 3 [label="port 1"];
+	6 -> 7;
+}
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, g.ExportJSON(&buf))
+	var res Export
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, 7, len(res.Vertices))
+	assert.Equal(t, VertexInfo{Id: 5, Type: "Feedback", Scope: "loop2", Children: []vertex.Id{3}}, res.Vertices[4])
+	assert.Equal(t, VertexInfo{Id: 7, Type: "Inspect", Name: "sink", Children: []vertex.Id{}}, res.Vertices[6])
+	assert.Equal(t, 7, len(res.Edges))
+	assert.Equal(t, EdgeInfo{Src: 5, Target: 3, Port: 1}, res.Edges[5])
+
+	// Fusing keeps the ports of the edges going out of the fused vertex.
+	assert.Nil(t, g.InsertEdgeToPort(edge.NewEdge(6, 7), 1))
+	assert.Nil(t, g.Fuse(4, 6, vertex.Type_Fused))
+	assert.Equal(t, map[vertex.Id]int{4: 1}, g.VertexMap[7].InPorts)
+}
+
+func TestCheck(t *testing.T) {
+	g := NewGraph()
+	BuildGraph(t, g)
+
+	ps1 := NewVertexPointStamp(1, timestamp.NewTimestamp())
+	ts := timestamp.NewTimestampWithParams(0, []int{5})
+	ps2 := NewEdgePointStamp(edge.NewEdge(3, 4), ts)
+	assert.Nil(t, g.IncreOC(ps1))
+	assert.Nil(t, g.IncreOC(ps2))
+	assert.Nil(t, g.Check(false))
+	assert.Nil(t, g.CheckFrontier(ps1, true))
+	assert.Nil(t, g.CheckFrontier(ps2, false))
+	assert.True(t, errors.Is(g.CheckFrontier(ps2, true), ErrInvariant))
+
+	g.ActivePsMap[ps2.Hash()].PC = 0
+	err := g.Check(false)
+	assert.True(t, errors.Is(err, ErrInvariant))
+	assert.EqualError(t, err, fmt.Sprintf(
+		"progress tracking invariant violated: pointstamp edge 3->4 at %s has precursor count 0 but 1 active pointstamps could result in it",
+		ts.ToString(),
+	))
+	g.ActivePsMap[ps2.Hash()].PC = 1
+
+	// Negative counts are only allowed if updates may arrive out of order
+	g.ActivePsMap[ps2.Hash()].OC = -1
+	assert.True(t, errors.Is(g.Check(false), ErrInvariant))
+	assert.Nil(t, g.Check(true))
+}
+
+func TestTrackerCheck(t *testing.T) {
+	tr := NewTracker(1)
+	BuildGraph(t, tr.graph)
+	tr.SetCheck(true)
+
+	ps1 := NewVertexPointStamp(1, timestamp.NewTimestamp())
+	ps2 := NewEdgePointStamp(edge.NewEdge(6, 7), timestamp.NewTimestamp())
+	assert.Nil(t, tr.IncreOC(ps1))
+	assert.Nil(t, tr.IncreOC(ps2))
+	inFrontier, err := tr.InFrontier(ps2)
+	assert.Nil(t, err)
+	assert.False(t, inFrontier)
+	assert.Nil(t, tr.DecreOC(ps1))
+	inFrontier, err = tr.InFrontier(ps2)
+	assert.Nil(t, err)
+	assert.True(t, inFrontier)
+
+	// Decrementing a pointstamp which is not active
+	assert.True(t, errors.Is(tr.DecreOC(ps1), ErrInvariant))
+
+	// A precursor count which is off
+	tr.graph.ActivePsMap[ps2.Hash()].PC = 1
+	assert.True(t, errors.Is(tr.IncreOC(ps1), ErrInvariant))
+
+	// A pointstamp taken as in the frontier while another one could result in it
+	tr.graph.ActivePsMap[ps2.Hash()].PC = 0
+	_, err = tr.InFrontier(ps2)
+	assert.True(t, errors.Is(err, ErrInvariant))
+}
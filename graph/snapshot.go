@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// VertexInfo describes a vertex of the graph with the vertices it sends to.
+type VertexInfo struct {
+	Id       vertex.Id   `json:"id"`
+	Type     string      `json:"type"`
+	Name     string      `json:"name,omitempty"`
+	Scope    string      `json:"scope,omitempty"`
+	Children []vertex.Id `json:"children"`
+}
+
+// EdgeInfo describes an edge of the graph with the input port of its target.
+type EdgeInfo struct {
+	Src    vertex.Id `json:"src"`
+	Target vertex.Id `json:"target"`
+	Port   int       `json:"port"`
+}
+
+// PointstampInfo describes an active pointstamp with its occurrence and precursor counts.
+// A pointstamp on a vertex has the vertex as both Src and Target.
+type PointstampInfo struct {
+	Src    vertex.Id           `json:"src"`
+	Target vertex.Id           `json:"target"`
+	Ts     timestamp.Timestamp `json:"ts"`
+	OC     int                 `json:"oc"`
+	PC     int                 `json:"pc"`
+}
+
+// Vertices returns all vertices ordered by id, with their children ordered by id.
+func (g *Graph) Vertices() []VertexInfo {
+	res := []VertexInfo{}
+	for vid, node := range g.VertexMap {
+		children := []vertex.Id{}
+		for child := range node.Children {
+			children = append(children, child.Vid)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+		res = append(res, VertexInfo{
+			Id:       vid,
+			Type:     node.Type.String(),
+			Name:     node.Name,
+			Scope:    node.Scope,
+			Children: children,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+	return res
+}
+
+// Edges returns all edges ordered by source and then by target.
+func (g *Graph) Edges() []EdgeInfo {
+	res := []EdgeInfo{}
+	for vid, node := range g.VertexMap {
+		for child := range node.Children {
+			res = append(res, EdgeInfo{
+				Src:    vid,
+				Target: child.Vid,
+				Port:   child.InPorts[vid],
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Src != res[j].Src {
+			return res[i].Src < res[j].Src
+		}
+		return res[i].Target < res[j].Target
+	})
+	return res
+}
+
+// Pointstamps returns all active pointstamps ordered by location and then by timestamp.
+func (g *Graph) Pointstamps() []PointstampInfo {
+	res := []PointstampInfo{}
+	for _, counter := range g.ActivePsMap {
+		res = append(res, PointstampInfo{
+			Src:    counter.PS.GetSrc(),
+			Target: counter.PS.GetTarget(),
+			Ts:     *timestamp.CopyTimestampFrom(counter.PS.GetTimestamp()),
+			OC:     counter.OC,
+			PC:     counter.PC,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Src != res[j].Src {
+			return res[i].Src < res[j].Src
+		}
+		if res[i].Target != res[j].Target {
+			return res[i].Target < res[j].Target
+		}
+		return lessTimestamp(&res[i].Ts, &res[j].Ts)
+	})
+	return res
+}
+
+// lessTimestamp orders timestamps by epoch and then counters, to list them in a stable order.
+func lessTimestamp(a *timestamp.Timestamp, b *timestamp.Timestamp) bool {
+	if a.Epoch != b.Epoch {
+		return a.Epoch < b.Epoch
+	}
+	for i := 0; i < len(a.Counters) && i < len(b.Counters); i++ {
+		if a.Counters[i] != b.Counters[i] {
+			return a.Counters[i] < b.Counters[i]
+		}
+	}
+	return len(a.Counters) < len(b.Counters)
+}
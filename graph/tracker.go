@@ -0,0 +1,304 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// Tracker is the progress tracker shared by the workers of a process.
+// Every worker builds the same dataflow, so vertices and edges are logical
+// locations, and occurrence counts of pointstamps are summed over all workers.
+// This way every worker sees the same frontier.
+type Tracker struct {
+	mu      sync.Mutex
+	graph   *Graph
+	workers int
+	once    sync.Once
+	// Broadcasts updates of local workers to other processes, nil if the dataflow
+	// runs in a single process.
+	exchange ProgressExchange
+	// Channels to signal workers that the frontier may have moved.
+	subscribers []chan struct{}
+	// Number of occurrence count updates so far.
+	updates uint64
+	// Whether all counts are verified after every update, see SetCheck.
+	check bool
+}
+
+// ProgressExchange broadcasts the progress updates of local workers to other processes.
+// Once the updates are broadcast, the decrements are applied back with Apply.
+type ProgressExchange interface {
+	Record(ps Pointstamp, delta int)
+}
+
+// NewTracker creates a tracker for the given number of workers in total,
+// including the workers of other processes.
+func NewTracker(workers int) *Tracker {
+	return &Tracker{
+		graph:       NewGraph(),
+		workers:     workers,
+		subscribers: []chan struct{}{},
+	}
+}
+
+// Subscribe returns a channel which gets a signal whenever the frontier
+// may have moved. Signals are coalesced, so a worker receiving one
+// should check all of its pending notifications.
+func (t *Tracker) Subscribe() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	t.subscribers = append(t.subscribers, ch)
+	return ch
+}
+
+func (t *Tracker) InsertVertex(vid vertex.Id, typ vertex.Type) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.graph.InsertVertex(vid, typ)
+}
+
+func (t *Tracker) InsertEdge(e edge.Edge) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.InsertEdge(e)
+}
+
+// InsertEdgeToPort inserts an edge going into the given input port of its target.
+func (t *Tracker) InsertEdgeToPort(e edge.Edge, port int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.InsertEdgeToPort(e, port)
+}
+
+// SetScope sets the path of the loop scopes the vertex is nested in.
+func (t *Tracker) SetScope(vid vertex.Id, scope string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.SetScope(vid, scope)
+}
+
+// SetName sets the name of the operator of a vertex, see Graph.SetName.
+func (t *Tracker) SetName(vid vertex.Id, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.SetName(vid, name)
+}
+
+// Fuse merges vertex next into vertex head, see Graph.Fuse.
+// Every worker fuses its own copy of the dataflow, so the same merge may happen more than once.
+func (t *Tracker) Fuse(head vertex.Id, next vertex.Id, typ vertex.Type) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.Fuse(head, next, typ)
+}
+
+// PreProcess initializes the pointstamps of the input vertices once for all workers.
+// Every worker holds the pointstamp at its own instance of an input vertex,
+// so the occurrence count starts with the number of workers.
+// Updates from other processes may already be applied, so the counts are added
+// rather than overwritten.
+func (t *Tracker) PreProcess() error {
+	var err error
+	t.once.Do(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for vid, node := range t.graph.VertexMap {
+			if node.Type != vertex.Type_Input {
+				continue
+			}
+			ps := NewVertexPointStamp(vid, timestamp.NewTimestamp())
+			if err = t.graph.UpdateOC(ps, t.workers); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// SetCheck makes the tracker verify the counts of all active pointstamps after every update,
+// and the frontier whenever it is asked if a pointstamp is in it, see Graph.Check.
+// On divergence the update causing it fails with an error wrapping ErrInvariant.
+// Checking recomputes all precursor counts from scratch, so it is only meant for debugging.
+func (t *Tracker) SetCheck(check bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.check = check
+}
+
+// verify checks all counts if checking is enabled. It is called with the lock held.
+func (t *Tracker) verify() error {
+	if !t.check {
+		return nil
+	}
+	// Updates from other processes may arrive out of order
+	return t.graph.Check(t.exchange != nil)
+}
+
+// SetExchange makes the tracker broadcast updates of local workers through x.
+func (t *Tracker) SetExchange(x ProgressExchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchange = x
+}
+
+// IncreOC increments the occurrence count of the pointstamp.
+// Increments are applied locally at once, which only holds the frontier back.
+func (t *Tracker) IncreOC(ps Pointstamp) error {
+	x, err := t.increOC(ps)
+	if err != nil {
+		return err
+	}
+	if x != nil {
+		x.Record(ps, 1)
+	}
+	return nil
+}
+
+// increOC applies the increment locally and returns the exchange to broadcast it through.
+func (t *Tracker) increOC(ps Pointstamp) (ProgressExchange, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updates++
+	// A decrement from another process may have arrived first, see Graph.UpdateOC.
+	if err := t.graph.UpdateOC(ps, 1); err != nil {
+		return nil, err
+	}
+	if err := t.verify(); err != nil {
+		return nil, err
+	}
+	if _, exist := t.graph.ActivePsMap[ps.Hash()]; !exist {
+		// The increment cancelled the decrement, so the frontier may have moved.
+		t.signal()
+	}
+	return t.exchange, nil
+}
+
+// DecreOC decrements the occurrence count of the pointstamp
+// and signals all workers, as the frontier may have moved.
+// With a progress exchange, the decrement is only applied once it is broadcast
+// together with the increments it depends on, so no process sees the frontier move early.
+func (t *Tracker) DecreOC(ps Pointstamp) error {
+	t.mu.Lock()
+	t.updates++
+	x := t.exchange
+	if x != nil {
+		t.mu.Unlock()
+		x.Record(ps, -1)
+		return nil
+	}
+	defer t.mu.Unlock()
+	if err := t.graph.DecreOC(ps); err != nil {
+		if t.check {
+			return fmt.Errorf("%w: cannot decrement pointstamp %s: %v", ErrInvariant, describePs(ps), err)
+		}
+		return err
+	}
+	if err := t.verify(); err != nil {
+		return err
+	}
+	t.signal()
+	return nil
+}
+
+// Apply applies updates received from the progress exchange and signals all workers.
+// Increments are applied before decrements, so a batch never moves the frontier
+// past a pointstamp it creates.
+func (t *Tracker) Apply(updates []Update) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updates += uint64(len(updates))
+	for _, u := range updates {
+		if u.Delta > 0 {
+			if err := t.graph.UpdateOC(u.Ps, u.Delta); err != nil {
+				return err
+			}
+		}
+	}
+	for _, u := range updates {
+		if u.Delta < 0 {
+			if err := t.graph.UpdateOC(u.Ps, u.Delta); err != nil {
+				return err
+			}
+		}
+	}
+	if err := t.verify(); err != nil {
+		return err
+	}
+	t.signal()
+	return nil
+}
+
+func (t *Tracker) signal() {
+	for _, ch := range t.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Updates returns the number of occurrence count updates so far,
+// which keeps growing as long as the dataflow makes progress.
+func (t *Tracker) Updates() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.updates
+}
+
+// ActivePointstamps returns the number of pointstamps in the active set.
+func (t *Tracker) ActivePointstamps() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.graph.ActivePsMap)
+}
+
+// Vertices returns all vertices of the graph, see Graph.Vertices.
+func (t *Tracker) Vertices() []VertexInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.Vertices()
+}
+
+// Pointstamps returns all active pointstamps, see Graph.Pointstamps.
+func (t *Tracker) Pointstamps() []PointstampInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.Pointstamps()
+}
+
+// ExportJSON writes the graph as JSON, see Graph.ExportJSON.
+func (t *Tracker) ExportJSON(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.ExportJSON(w)
+}
+
+// ExportDOT writes the graph in the DOT language, see Graph.ExportDOT.
+func (t *Tracker) ExportDOT(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph.ExportDOT(w)
+}
+
+// InFrontier tells whether an active pointstamp is in the frontier,
+// which means no other active pointstamp could-result-in it.
+func (t *Tracker) InFrontier(ps Pointstamp) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	psCounter, exist := t.graph.ActivePsMap[ps.Hash()]
+	if !exist {
+		return false, fmt.Errorf("pointstamp not active: %s", ps.GetTimestamp().ToString())
+	}
+	if t.check {
+		if err := t.graph.CheckFrontier(ps, psCounter.PC == 0); err != nil {
+			return false, err
+		}
+	}
+	return psCounter.PC == 0, nil
+}
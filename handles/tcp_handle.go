@@ -0,0 +1,200 @@
+package handles
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/constants"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+// Tags in front of each encoded request, telling which channel
+// of the remote handle the request is delivered to.
+const (
+	tagTask byte = iota
+	tagAck
+)
+
+// tcpConn sends requests as frames over a connection and dispatches
+// received frames to channels according to their tags.
+type tcpConn struct {
+	conn net.Conn
+	// Serializes writes so frames from different goroutines do not interleave.
+	mu    sync.Mutex
+	chs   map[byte]chan request.Request
+	done  chan struct{}
+	err   error
+	close sync.Once
+}
+
+func newTcpConn(conn net.Conn, tags ...byte) *tcpConn {
+	c := &tcpConn{
+		conn: conn,
+		chs:  make(map[byte]chan request.Request),
+		done: make(chan struct{}),
+	}
+	for _, tag := range tags {
+		c.chs[tag] = make(chan request.Request, constants.ChanCapacity)
+	}
+	go c.readLoop()
+	return c
+}
+
+func (c *tcpConn) write(tag byte, req *request.Request) error {
+	buf, err := request.Marshal(req)
+	if err != nil {
+		return err
+	}
+	payload := append([]byte{tag}, buf...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return request.WriteFrame(c.conn, payload)
+}
+
+func (c *tcpConn) readLoop() {
+	for {
+		payload, err := request.ReadFrame(c.conn)
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		if len(payload) == 0 {
+			c.shutdown(errors.New("empty frame received"))
+			return
+		}
+		ch, exist := c.chs[payload[0]]
+		if !exist {
+			c.shutdown(errors.New("frame received with unknown tag"))
+			return
+		}
+		req, err := request.Unmarshal(payload[1:])
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		select {
+		case ch <- *req:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// shutdown closes the connection and records the reason.
+// A connection closed by either side is not an error.
+func (c *tcpConn) shutdown(err error) {
+	c.close.Do(func() {
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+			utils.Logger().Error(err.Error())
+			c.err = err
+		}
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+// closeErr returns the error which closed the connection,
+// or nil if the connection is still open or closed normally.
+func (c *tcpConn) closeErr() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// TcpVertexHandle is the VertexHandle for a vertex in another process.
+// Requests sent or acked on one end of the connection are received
+// from MsgRecv or AckRecv of the handle on the other end.
+type TcpVertexHandle struct {
+	c *tcpConn
+}
+
+func NewTcpVertexHandle(conn net.Conn) *TcpVertexHandle {
+	return &TcpVertexHandle{
+		c: newTcpConn(conn, tagTask, tagAck),
+	}
+}
+
+func (h *TcpVertexHandle) Send(req *request.Request) {
+	if err := h.c.write(tagTask, req); err != nil {
+		h.c.shutdown(err)
+	}
+}
+
+func (h *TcpVertexHandle) Ack(req *request.Request) {
+	if err := h.c.write(tagAck, req); err != nil {
+		h.c.shutdown(err)
+	}
+}
+
+func (h *TcpVertexHandle) MsgRecv() chan request.Request {
+	return h.c.chs[tagTask]
+}
+
+func (h *TcpVertexHandle) AckRecv() chan request.Request {
+	return h.c.chs[tagAck]
+}
+
+// Done is closed when the connection is closed.
+func (h *TcpVertexHandle) Done() <-chan struct{} {
+	return h.c.done
+}
+
+// Err returns the error which closed the connection, if any.
+func (h *TcpVertexHandle) Err() error {
+	return h.c.closeErr()
+}
+
+func (h *TcpVertexHandle) Close() error {
+	h.c.shutdown(nil)
+	return nil
+}
+
+// TcpWorkerHandle is the WorkerHandle for a worker in another process.
+// Requests sent on one end of the connection are received from Recv
+// of the handle on the other end.
+type TcpWorkerHandle struct {
+	c *tcpConn
+}
+
+func NewTcpWorkerHandle(conn net.Conn) *TcpWorkerHandle {
+	return &TcpWorkerHandle{
+		c: newTcpConn(conn, tagTask),
+	}
+}
+
+func (h *TcpWorkerHandle) Send(req *request.Request) error {
+	select {
+	case <-h.c.done:
+		if err := h.c.closeErr(); err != nil {
+			return err
+		}
+		return net.ErrClosed
+	default:
+	}
+	return h.c.write(tagTask, req)
+}
+
+func (h *TcpWorkerHandle) Recv() chan request.Request {
+	return h.c.chs[tagTask]
+}
+
+// Done is closed when the connection is closed.
+func (h *TcpWorkerHandle) Done() <-chan struct{} {
+	return h.c.done
+}
+
+// Err returns the error which closed the connection, if any.
+func (h *TcpWorkerHandle) Err() error {
+	return h.c.closeErr()
+}
+
+func (h *TcpWorkerHandle) Close() error {
+	h.c.shutdown(nil)
+	return nil
+}
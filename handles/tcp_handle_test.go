@@ -0,0 +1,77 @@
+package handles
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+// loopback returns both ends of a TCP connection on localhost.
+func loopback(t *testing.T) (net.Conn, net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := l.Accept()
+		assert.Nil(t, err)
+		accepted <- conn
+	}()
+	client, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	return client, <-accepted
+}
+
+func TestTcpVertexHandle(t *testing.T) {
+	c1, c2 := loopback(t)
+	h1 := NewTcpVertexHandle(c1)
+	h2 := NewTcpVertexHandle(c2)
+
+	h1.Send(&request.Request{
+		Type: request.Type_OnRecv,
+		Edge: edge.NewEdge(1, 2),
+		Msg:  *request.NewMessage([]byte("msg")),
+		Ts:   *timestamp.NewTimestamp(),
+	})
+	h2.Ack(&request.Request{Type: request.Type_Ack})
+
+	req := <-h2.MsgRecv()
+	assert.Equal(t, request.Type_OnRecv, req.Type)
+	assert.Equal(t, "msg", req.Msg.ToString())
+	assert.Equal(t, *timestamp.NewTimestamp(), req.Ts)
+
+	req = <-h1.AckRecv()
+	assert.Equal(t, request.Type_Ack, req.Type)
+
+	assert.Nil(t, h1.Close())
+	<-h2.Done()
+	assert.Nil(t, h2.Err())
+}
+
+func TestTcpWorkerHandle(t *testing.T) {
+	c1, c2 := loopback(t)
+	h1 := NewTcpWorkerHandle(c1)
+	h2 := NewTcpWorkerHandle(c2)
+
+	for i := 0; i < 100; i++ {
+		err := h1.Send(&request.Request{
+			Type: request.Type_SendBy,
+			Edge: edge.NewEdge(1, 2),
+			Ts:   *timestamp.NewTimestampWithParams(i, []int{0}),
+		})
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 100; i++ {
+		req := <-h2.Recv()
+		assert.Equal(t, i, req.Ts.Epoch)
+	}
+
+	assert.Nil(t, h2.Close())
+	<-h1.Done()
+	assert.Error(t, h1.Send(&request.Request{Type: request.Type_SendBy}))
+}
@@ -6,7 +6,7 @@ import (
 
 // VertexHandle is the handle that is held by worker to communicate with Vertex.
 // If the vertex is within the scope of a worker, then the handler contains a chan.
-// Otherwise it contains network sockets to communicate with workers in other processes.
+// Otherwise it contains network sockets to communicate with workers in other processes, see TcpVertexHandle.
 type VertexHandle interface {
 	Send(req *request.Request)
 	Ack(req *request.Request)
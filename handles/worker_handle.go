@@ -1,6 +1,8 @@
 package handles
 
 import (
+	"errors"
+
 	"github.com/stepneko/neko-dataflow/constants"
 	"github.com/stepneko/neko-dataflow/request"
 )
@@ -12,6 +14,8 @@ type WorkerHandle interface {
 
 type SimpleWorkerHandle struct {
 	ch chan request.Request
+	// Sends give up once done is closed. Nil if they never do.
+	done <-chan struct{}
 }
 
 func NewSimpleWorkerHandle() *SimpleWorkerHandle {
@@ -20,11 +24,45 @@ func NewSimpleWorkerHandle() *SimpleWorkerHandle {
 	}
 }
 
+// NewSimpleWorkerHandleWithDone creates a handle whose sends fail once done is closed,
+// so that a sender is not blocked forever by a worker which stopped with a full channel.
+func NewSimpleWorkerHandleWithDone(done <-chan struct{}) *SimpleWorkerHandle {
+	h := NewSimpleWorkerHandle()
+	h.done = done
+	return h
+}
+
 func (t *SimpleWorkerHandle) Send(req *request.Request) error {
-	t.ch <- *req
-	return nil
+	select {
+	case t.ch <- *req:
+		return nil
+	case <-t.done:
+		return errors.New("worker is done")
+	}
 }
 
 func (t *SimpleWorkerHandle) Recv() chan request.Request {
 	return t.ch
 }
+
+// SyncWorkerHandle handles every request at once in the goroutine of the sender.
+// It is used by a worker stepping all its vertices in one goroutine, where a vertex
+// waiting for an ack would otherwise block the worker forever.
+type SyncWorkerHandle struct {
+	fn func(*request.Request) error
+}
+
+func NewSyncWorkerHandle(fn func(*request.Request) error) *SyncWorkerHandle {
+	return &SyncWorkerHandle{
+		fn: fn,
+	}
+}
+
+func (t *SyncWorkerHandle) Send(req *request.Request) error {
+	return t.fn(req)
+}
+
+// Recv returns nil, as requests are never queued.
+func (t *SyncWorkerHandle) Recv() chan request.Request {
+	return nil
+}
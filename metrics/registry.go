@@ -0,0 +1,327 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Labels are the label names and values identifying one series of a metric.
+type Labels map[string]string
+
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// metric is one series of a metric family.
+type metric interface {
+	samples(name string, labels Labels) []sample
+}
+
+type sample struct {
+	name   string
+	labels Labels
+	value  float64
+}
+
+type family struct {
+	name   string
+	help   string
+	typ    metricType
+	series map[string]metric
+	labels map[string]Labels
+}
+
+// Registry holds all metrics of a process. Workers and operators create their metrics
+// in the registry they are given, and the registry renders all of them in the
+// Prometheus text format.
+//
+// Creating a metric with the same name and labels twice returns the same metric,
+// so workers sharing a registry report into the same series.
+// All methods can be called on a nil registry, which returns nil metrics
+// that ignore all updates.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// Counter returns the counter with the given name and labels, creating it if needed.
+func (r *Registry) Counter(name string, help string, labels Labels) *Counter {
+	if r == nil {
+		return nil
+	}
+	return r.getOrCreate(name, help, typeCounter, labels, func() metric {
+		return &Counter{}
+	}).(*Counter)
+}
+
+// Gauge returns the gauge with the given name and labels, creating it if needed.
+func (r *Registry) Gauge(name string, help string, labels Labels) *Gauge {
+	if r == nil {
+		return nil
+	}
+	return r.getOrCreate(name, help, typeGauge, labels, func() metric {
+		return &Gauge{}
+	}).(*Gauge)
+}
+
+// GaugeFunc registers a gauge whose value is computed by f whenever the metrics are rendered.
+// If the gauge already exists, the function registered first is kept.
+func (r *Registry) GaugeFunc(name string, help string, labels Labels, f func() float64) {
+	if r == nil {
+		return
+	}
+	r.getOrCreate(name, help, typeGauge, labels, func() metric {
+		return gaugeFunc(f)
+	})
+}
+
+// Histogram returns the histogram with the given name and labels, creating it if needed.
+// Buckets are the upper bounds of the buckets in increasing order, the +Inf bucket is implicit.
+// If the histogram already exists, it keeps the buckets it was created with.
+func (r *Registry) Histogram(name string, help string, labels Labels, buckets []float64) *Histogram {
+	if r == nil {
+		return nil
+	}
+	return r.getOrCreate(name, help, typeHistogram, labels, func() metric {
+		return newHistogram(buckets)
+	}).(*Histogram)
+}
+
+func (r *Registry) getOrCreate(
+	name string,
+	help string,
+	typ metricType,
+	labels Labels,
+	create func() metric,
+) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, exist := r.families[name]
+	if !exist {
+		f = &family{
+			name:   name,
+			help:   help,
+			typ:    typ,
+			series: make(map[string]metric),
+			labels: make(map[string]Labels),
+		}
+		r.families[name] = f
+	}
+	if f.typ != typ {
+		panic(fmt.Sprintf("metric %s is registered as %s, not %s", name, f.typ, typ))
+	}
+
+	key := formatLabels(labels)
+	m, exist := f.series[key]
+	if !exist {
+		m = create()
+		f.series[key] = m
+		f.labels[key] = copyLabels(labels)
+	}
+	return m
+}
+
+func copyLabels(labels Labels) Labels {
+	res := Labels{}
+	for k, v := range labels {
+		res[k] = v
+	}
+	return res
+}
+
+// formatLabels renders labels as in the text format, ordered by name.
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := []string{}
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{}
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", name, escapeLabel(labels[name])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer("\\", `\\`, "\"", `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// addFloat adds v to the float64 stored as bits in addr.
+func addFloat(addr *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		new := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(addr, old, new) {
+			return
+		}
+	}
+}
+
+// Counter is a value which only goes up, such as the number of handled messages.
+type Counter struct {
+	bits uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds v to the counter. Negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if c == nil || v < 0 {
+		return
+	}
+	addFloat(&c.bits, v)
+}
+
+func (c *Counter) Value() float64 {
+	if c == nil {
+		return 0
+	}
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+func (c *Counter) samples(name string, labels Labels) []sample {
+	return []sample{{name: name, labels: labels, value: c.Value()}}
+}
+
+// Gauge is a value which can go up and down, such as the length of a queue.
+type Gauge struct {
+	bits uint64
+}
+
+func (g *Gauge) Set(v float64) {
+	if g == nil {
+		return
+	}
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *Gauge) Add(v float64) {
+	if g == nil {
+		return
+	}
+	addFloat(&g.bits, v)
+}
+
+func (g *Gauge) Value() float64 {
+	if g == nil {
+		return 0
+	}
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func (g *Gauge) samples(name string, labels Labels) []sample {
+	return []sample{{name: name, labels: labels, value: g.Value()}}
+}
+
+type gaugeFunc func() float64
+
+func (f gaugeFunc) samples(name string, labels Labels) []sample {
+	return []sample{{name: name, labels: labels, value: f()}}
+}
+
+// Histogram counts observed values, such as latencies, in buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: append([]float64{}, buckets...),
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i] += 1
+		}
+	}
+	h.count += 1
+	h.sum += v
+}
+
+// Count returns the number of observed values.
+func (h *Histogram) Count() uint64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the sum of observed values.
+func (h *Histogram) Sum() float64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+func (h *Histogram) samples(name string, labels Labels) []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	res := []sample{}
+	for i, bound := range h.buckets {
+		l := copyLabels(labels)
+		l["le"] = formatValue(bound)
+		res = append(res, sample{name: name + "_bucket", labels: l, value: float64(h.counts[i])})
+	}
+	l := copyLabels(labels)
+	l["le"] = "+Inf"
+	res = append(res,
+		sample{name: name + "_bucket", labels: l, value: float64(h.count)},
+		sample{name: name + "_sum", labels: labels, value: h.sum},
+		sample{name: name + "_count", labels: labels, value: float64(h.count)},
+	)
+	return res
+}
+
+// ExponentialBuckets returns count bucket bounds, starting at start and each factor times the previous one.
+func ExponentialBuckets(start float64, factor float64, count int) []float64 {
+	res := []float64{}
+	bound := start
+	for i := 0; i < count; i++ {
+		res = append(res, bound)
+		bound *= factor
+	}
+	return res
+}
+
+// LatencyBuckets are buckets for latencies in seconds, from one microsecond to about one second.
+var LatencyBuckets = ExponentialBuckets(0.000001, 4, 11)
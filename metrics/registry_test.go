@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryText(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.Counter("requests_total", "Number of requests.", Labels{"worker": "1", "type": "a\"b"})
+	c.Inc()
+	c.Add(2)
+	// Same name and labels return the same counter
+	r.Counter("requests_total", "Number of requests.", Labels{"type": "a\"b", "worker": "1"}).Inc()
+	r.Counter("requests_total", "Number of requests.", Labels{"worker": "0", "type": "x"})
+
+	g := r.Gauge("queue_length", "Length of\nthe queue.", nil)
+	g.Set(5)
+	g.Add(-2)
+
+	r.GaugeFunc("active", "Active things.", Labels{"worker": "0"}, func() float64 { return 7 })
+
+	h := r.Histogram("latency_seconds", "Latency.", Labels{"worker": "0"}, []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	var buf bytes.Buffer
+	assert.Nil(t, r.WriteText(&buf))
+	assert.Equal(t, `# HELP active Active things.
+# TYPE active gauge
+active{worker="0"} 7
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1",worker="0"} 1
+latency_seconds_bucket{le="1",worker="0"} 2
+latency_seconds_bucket{le="+Inf",worker="0"} 3
+latency_seconds_sum{worker="0"} 2.55
+latency_seconds_count{worker="0"} 3
+# HELP queue_length Length of\nthe queue.
+# TYPE queue_length gauge
+queue_length 3
+# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{type="a\"b",worker="1"} 4
+requests_total{type="x",worker="0"} 0
+`, buf.String())
+
+	assert.Panics(t, func() { r.Gauge("requests_total", "", nil) })
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+
+	c := r.Counter("requests_total", "Number of requests.", nil)
+	c.Inc()
+	assert.Equal(t, float64(0), c.Value())
+	r.Gauge("queue_length", "", nil).Set(1)
+	r.Histogram("latency_seconds", "", nil, LatencyBuckets).Observe(1)
+	r.GaugeFunc("active", "", nil, func() float64 { return 1 })
+
+	var buf bytes.Buffer
+	assert.Nil(t, r.WriteText(&buf))
+	assert.Equal(t, "", buf.String())
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "Number of requests.", nil).Inc()
+
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, "# HELP requests_total Number of requests.\n# TYPE requests_total counter\nrequests_total 1\n", string(body))
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var helpEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`)
+
+// WriteText writes all metrics in the Prometheus text format,
+// with families ordered by name and series ordered by labels.
+func (r *Registry) WriteText(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	families := []*family{}
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	type series struct {
+		m      metric
+		labels Labels
+	}
+	all := make(map[*family][]series)
+	for _, f := range families {
+		keys := []string{}
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			all[f] = append(all[f], series{m: f.series[key], labels: f.labels[key]})
+		}
+	}
+	// Gauge functions are called without the lock, so they may read other locked state.
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range all[f] {
+			for _, smp := range s.m.samples(f.name, s.labels) {
+				fmt.Fprintf(bw, "%s%s %s\n", smp.name, formatLabels(smp.labels), formatValue(smp.value))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	if math.IsInf(v, -1) {
+		return "-Inf"
+	}
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler returns an HTTP handler serving all metrics of the registry in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		if err := r.WriteText(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(buf.Bytes())
+	})
+}
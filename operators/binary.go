@@ -9,7 +9,6 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
 )
 
 type BinaryType int
@@ -49,26 +48,35 @@ func (op *BinaryOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle1.MsgRecv():
-			if err := op.handleReq(&req, BinaryType_Left); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Left) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		case req := <-op.handle2.MsgRecv():
-			if err := op.handleReq(&req, BinaryType_Right); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Right) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *BinaryOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle1.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Left) })
+	case req := <-op.handle2.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Right) })
+	default:
+		return false, nil
+	}
+}
+
 func (op *BinaryOpCore) handleReq(req *request.Request, bt BinaryType) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		if bt == BinaryType_Left {
@@ -92,36 +100,12 @@ func (op *BinaryOpCore) handleReq(req *request.Request, bt BinaryType) error {
 }
 
 func (op *BinaryOpCore) OnRecv1(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle1); err != nil {
-		return err
-	}
-
 	// Send the result message to next target to continue the dataflow
 	iter, err := op.f1(e, msg, ts)
-	if err != nil {
-		return err
-	}
-
-	if iter == nil {
-		return nil
-	}
-
-	for {
-		flag, err := iter.HasElement()
-		if err != nil {
-			return err
-		}
-		if !flag {
-			return nil
-		}
-		m, err := iter.Iter()
-		if err != nil {
-			return err
-		}
-		if err := op.SendBy(edge.NewEdge(op.id, op.target), m, ts); err != nil {
-			return err
-		}
+	if err == nil {
+		err = op.coreSendIter(iter, edge.NewEdge(op.id, op.target), ts, op.handle1)
 	}
+	return op.coreRetire(e, ts, op.handle1, err)
 }
 
 func (op *BinaryOpCore) OnRecv2(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
@@ -132,36 +116,12 @@ func (op *BinaryOpCore) OnRecv2(e edge.Edge, msg *request.Message, ts timestamp.
 	//
 	// Actually from the worker side, the worker picks vertex handle by map[src, src] or
 	// map[target, target], rather than map[src, target], therefore only handle1 will be picked.
-	if err := op.coreDecreOC(e, ts, op.handle1); err != nil {
-		return err
-	}
-
 	// Send the result message to next target to continue the dataflow
 	iter, err := op.f2(e, msg, ts)
-	if err != nil {
-		return err
-	}
-
-	if iter == nil {
-		return nil
-	}
-
-	for {
-		flag, err := iter.HasElement()
-		if err != nil {
-			return err
-		}
-		if !flag {
-			return nil
-		}
-		m, err := iter.Iter()
-		if err != nil {
-			return err
-		}
-		if err := op.SendBy(edge.NewEdge(op.id, op.target), m, ts); err != nil {
-			return err
-		}
+	if err == nil {
+		err = op.coreSendIter(iter, edge.NewEdge(op.id, op.target), ts, op.handle1)
 	}
+	return op.coreRetire(e, ts, op.handle1, err)
 }
 
 func (op *BinaryOpCore) OnNotify1(ts timestamp.Timestamp) error {
@@ -0,0 +1,99 @@
+package operators
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+)
+
+type BranchHandle interface {
+	handles.VertexHandle
+}
+
+type BranchOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+	// Else returns the stream of messages rejected by the branch callback.
+	Else() Operator
+}
+
+type BranchOpCore struct {
+	*OpCore
+	elseOp *OpCore
+	handle BranchHandle
+	f      FilterCallback
+}
+
+func (op *BranchOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *BranchOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *BranchOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *BranchOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	flag, err := op.f(e, msg, ts)
+	if err == nil {
+		if flag {
+			err = op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
+		} else {
+			err = op.SendBy(edge.NewEdge(op.id, op.elseOp.target), msg, ts)
+		}
+	}
+	return op.coreRetire(e, ts, op.handle, err)
+}
+
+func (op *BranchOpCore) OnNotify(ts timestamp.Timestamp) error {
+	return nil
+}
+
+func (op *BranchOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *BranchOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return nil
+}
+
+func (op *BranchOpCore) Else() Operator {
+	return op.elseOp
+}
@@ -3,22 +3,41 @@ package operators
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/stepneko/neko-dataflow/constants"
 	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/events"
 	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/tracing"
 	"github.com/stepneko/neko-dataflow/vertex"
+	"go.uber.org/zap"
 )
 
 type OpCore struct {
 	scope.Scope
 	id     vertex.Id
 	typ    vertex.Type
+	name   string
 	currTs timestamp.Timestamp
+	// Guards currTs, which is also read by the debug server.
+	tsMu   sync.Mutex
 	target vertex.Id
+	// Metrics and logger are created on first use, so vertices merged away by fusion
+	// never show up in the registry.
+	metricsOnce sync.Once
+	metrics     *opMetrics
+	loggerOnce  sync.Once
+	logger      *zap.Logger
+	// Number of messages sent by the vertex, which tells how many messages a traced span sent.
+	sentCount int
+	// 1 while the vertex waits for the worker to ack a request, read by the watchdog.
+	blocked int32
 }
 
 func NewOpCore(
@@ -44,10 +63,19 @@ func (op *OpCore) Type() vertex.Type {
 	return op.typ
 }
 
+// Label returns the name given to the operator by WithName.
+func (op *OpCore) Label() string {
+	return op.name
+}
+
 func (op *OpCore) Start(wg *sync.WaitGroup) error {
 	return errors.New("raw opcore trying to run Start() with no operater specific Start()")
 }
 
+func (op *OpCore) Schedule() (bool, error) {
+	return false, errors.New("raw opcore trying to run Schedule() with no operater specific Schedule()")
+}
+
 // ===================== Impl Operator interface ================== //
 
 func (op *OpCore) AsScope() scope.Scope {
@@ -58,7 +86,19 @@ func (op *OpCore) SetTarget(vid vertex.Id) {
 	op.target = vid
 }
 
-func (op *OpCore) Inspect(f DataCallback) InspectOp {
+func (op *OpCore) getTarget() vertex.Id {
+	return op.target
+}
+
+// withOptions applies the options given to an operator constructor.
+// It has to be called before the vertex is registered.
+func (op *OpCore) withOptions(opts []OpOption) *OpCore {
+	config := newOpConfig(opts)
+	op.name = config.name
+	return op
+}
+
+func (op *OpCore) Inspect(f DataCallback, opts ...OpOption) InspectOp {
 	s := op.AsScope()
 
 	taskCh := make(chan request.Request, constants.ChanCapacity)
@@ -70,7 +110,7 @@ func (op *OpCore) Inspect(f DataCallback) InspectOp {
 	vid := s.GenerateVID()
 
 	v := &InspectOpCore{
-		OpCore: NewOpCore(vid, vertex.Type_Inspect, s),
+		OpCore: NewOpCore(vid, vertex.Type_Inspect, s).withOptions(opts),
 		handle: handle,
 		f:      f,
 	}
@@ -82,7 +122,7 @@ func (op *OpCore) Inspect(f DataCallback) InspectOp {
 	return v
 }
 
-func (op *OpCore) Binary(other Operator, f1 DataCallback, f2 DataCallback) BinaryOp {
+func (op *OpCore) Binary(other Operator, f1 DataCallback, f2 DataCallback, opts ...OpOption) BinaryOp {
 	s := op.AsScope()
 
 	taskCh1 := make(chan request.Request, constants.ChanCapacity)
@@ -97,7 +137,7 @@ func (op *OpCore) Binary(other Operator, f1 DataCallback, f2 DataCallback) Binar
 	vid := s.GenerateVID()
 
 	v := &BinaryOpCore{
-		OpCore:  NewOpCore(vid, vertex.Type_Bianry, s),
+		OpCore:  NewOpCore(vid, vertex.Type_Bianry, s).withOptions(opts),
 		handle1: handle1,
 		handle2: handle2,
 
@@ -113,7 +153,43 @@ func (op *OpCore) Binary(other Operator, f1 DataCallback, f2 DataCallback) Binar
 	return v
 }
 
-func (op *OpCore) Filter(f FilterCallback) FilterOp {
+// Concat merges the stream of other into the stream of op.
+func (op *OpCore) Concat(other Operator, opts ...OpOption) BinaryOp {
+	identity := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+		return iterator.IterFromSingleton(msg), nil
+	}
+	return op.Binary(other, identity, identity, opts...)
+}
+
+// Branch splits the stream into two. Messages for which f returns true
+// continue on the returned operator, and the others on BranchOp.Else().
+func (op *OpCore) Branch(f FilterCallback, opts ...OpOption) BranchOp {
+	s := op.AsScope()
+
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := s.GenerateVID()
+
+	v := &BranchOpCore{
+		OpCore: NewOpCore(vid, vertex.Type_Branch, s).withOptions(opts),
+		// The else output shares the vertex id, and only keeps its own target.
+		elseOp: NewOpCore(vid, vertex.Type_Branch, s).withOptions(opts),
+		handle: handle,
+		f:      f,
+	}
+
+	s.RegisterVertex(v, handle)
+	s.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+func (op *OpCore) Filter(f FilterCallback, opts ...OpOption) FilterOp {
 	s := op.AsScope()
 
 	taskCh := make(chan request.Request, constants.ChanCapacity)
@@ -125,7 +201,7 @@ func (op *OpCore) Filter(f FilterCallback) FilterOp {
 	vid := s.GenerateVID()
 
 	v := &FilterOpCore{
-		OpCore: NewOpCore(vid, vertex.Type_Inspect, s),
+		OpCore: NewOpCore(vid, vertex.Type_Filter, s).withOptions(opts),
 		handle: handle,
 		f:      f,
 	}
@@ -137,30 +213,133 @@ func (op *OpCore) Filter(f FilterCallback) FilterOp {
 	return v
 }
 
-// Loop creates a loop structure in diagram:
-// op -> Ingress -[OnRecv1]-> IngressAdapter -> loop struct[func(ups)] -> EgressAdapter -[target1]-> Egress -> Onward...
-//                                  ^                                            |
-//                        [OnRecv2] |                                            |[target2]
-//                                  +------------------Feedback------------------+
-func (op *OpCore) Loop(dataF func(ups Operator) Operator, filterF FilterCallback) EgressOp {
+// Exchange routes each message to the worker owning its key,
+// so that all messages with the same key are processed by the same worker.
+// If key is nil, every message stays on the local worker.
+func (op *OpCore) Exchange(key KeyCallback, opts ...OpOption) ExchangeOp {
 	s := op.AsScope()
 
-	// Create ingress operator
-	ingressTaskCh1 := make(chan request.Request, constants.ChanCapacity)
-	ingressAckCh1 := make(chan request.Request, constants.ChanCapacity)
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := s.GenerateVID()
+
+	v := &ExchangeOpCore{
+		OpCore: NewOpCore(vid, vertex.Type_Exchange, s).withOptions(opts),
+		handle: handle,
+		key:    key,
+	}
+
+	s.RegisterVertex(v, handle)
+	s.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// SortBy buffers all messages of a timestamp and emits them ordered by less
+// once the timestamp is complete.
+func (op *OpCore) SortBy(less LessCallback, opts ...OpOption) SortOp {
+	s := op.AsScope()
+
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := s.GenerateVID()
+
+	v := &SortOpCore{
+		OpCore:  NewOpCore(vid, vertex.Type_Sort, s).withOptions(opts),
+		handle:  handle,
+		less:    less,
+		buffers: make(map[string]*tsBuffer),
+	}
+
+	s.RegisterVertex(v, handle)
+	s.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// TopK keeps the first k messages ordered by less for each timestamp,
+// grouped by key if key is not nil, and emits them once the timestamp is complete.
+func (op *OpCore) TopK(k int, less LessCallback, key KeyCallback, opts ...OpOption) TopKOp {
+	s := op.AsScope()
+
+	taskCh := make(chan request.Request, constants.ChanCapacity)
 
-	ingressHandle := handles.NewLocalVertexHandle(ingressTaskCh1, ingressAckCh1)
+	ackCh := make(chan request.Request, constants.ChanCapacity)
 
-	ingressVid := s.GenerateVID()
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
 
-	ingressOp := &IngressOpCore{
-		OpCore: NewOpCore(ingressVid, vertex.Type_Ingress, s),
-		handle: ingressHandle,
+	vid := s.GenerateVID()
+
+	v := &TopKOpCore{
+		OpCore:  NewOpCore(vid, vertex.Type_TopK, s).withOptions(opts),
+		handle:  handle,
+		k:       k,
+		less:    less,
+		key:     key,
+		buffers: make(map[string]*topKBuffer),
 	}
 
-	s.RegisterVertex(ingressOp, ingressHandle)
-	s.RegisterEdge(op, ingressOp, ingressHandle)
-	op.SetTarget(ingressVid)
+	s.RegisterVertex(v, handle)
+	s.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// Probe passes all messages through unchanged, and once a timestamp is complete,
+// reports how long ago the inputs opened its epoch into the probe, see LatencyProbe.
+func (op *OpCore) Probe(probe *LatencyProbe, opts ...OpOption) ProbeOp {
+	s := op.AsScope()
+
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := s.GenerateVID()
+
+	v := &ProbeOpCore{
+		OpCore:    NewOpCore(vid, vertex.Type_Probe, s).withOptions(opts),
+		handle:    handle,
+		probe:     probe,
+		opened:    probe.subscribe(),
+		requested: make(map[string]bool),
+	}
+
+	s.RegisterVertex(v, handle)
+	s.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// Loop creates a loop structure in diagram:
+// op -> Ingress -[OnRecv1]-> IngressAdapter -> loop struct[func(ups)] -> EgressAdapter -[target1]-> Egress -> Onward...
+//                                  ^                                            |
+//                        [OnRecv2] |                                            |[target2]
+//                                  +------------------Feedback------------------+
+//
+// The loop filter decides for each message whether to go another round.
+// See LoopOption for other conditions to terminate the loop.
+func (op *OpCore) Loop(dataF func(ups Operator) Operator, filterF FilterCallback, opts ...LoopOption) EgressOp {
+	config := newLoopConfig(opts)
+
+	// All vertices of the loop belong to a sub scope of the loop
+	sub := NewSubScope(op.AsScope(), config.name)
+
+	// Create ingress operator
+	ingressOp := sub.Enter(op)
 
 	// Create ingress adapter operator
 	ingressAdpTaskCh1 := make(chan request.Request, constants.ChanCapacity)
@@ -172,16 +351,16 @@ func (op *OpCore) Loop(dataF func(ups Operator) Operator, filterF FilterCallback
 	ingressAdpHandle1 := handles.NewLocalVertexHandle(ingressAdpTaskCh1, ingressAdpAckCh1)
 	ingressAdpHandle2 := handles.NewLocalVertexHandle(ingressAdpTaskCh2, ingressAdpAckCh2)
 
-	ingressAdpVid := s.GenerateVID()
+	ingressAdpVid := sub.GenerateVID()
 
 	ingressAdpOp := &IngressAdapterOpCore{
-		OpCore:  NewOpCore(ingressAdpVid, vertex.Type_IngressAdapter, s),
+		OpCore:  NewOpCore(ingressAdpVid, vertex.Type_IngressAdapter, sub),
 		handle1: ingressAdpHandle1,
 		handle2: ingressAdpHandle2,
 	}
 
-	s.RegisterVertex(ingressAdpOp, ingressAdpHandle1)
-	s.RegisterEdge(ingressOp, ingressAdpOp, ingressAdpHandle1)
+	sub.RegisterVertex(ingressAdpOp, ingressAdpHandle1)
+	sub.RegisterEdge(ingressOp, ingressAdpOp, ingressAdpHandle1)
 	ingressOp.SetTarget(ingressAdpVid)
 
 	// Make loop struct
@@ -193,60 +372,129 @@ func (op *OpCore) Loop(dataF func(ups Operator) Operator, filterF FilterCallback
 
 	egressAdpHandle := handles.NewLocalVertexHandle(egressAdpTaskCh, egressAdpAckCh)
 
-	egressAdpVid := s.GenerateVID()
+	egressAdpVid := sub.GenerateVID()
 
 	egressAdpOp := &EgressAdapterOpCore{
-		OpCore:  NewOpCore(egressAdpVid, vertex.Type_EgressAdapter, s),
+		OpCore:  NewOpCore(egressAdpVid, vertex.Type_EgressAdapter, sub),
 		handle:  egressAdpHandle,
 		target2: vertex.Id_Nil,
 		f:       filterF,
+		config:  config,
+
+		fixedPoints: make(map[string]*fixedPointState),
+		requested:   make(map[string]bool),
 	}
 
-	s.RegisterVertex(egressAdpOp, egressAdpHandle)
-	s.RegisterEdge(tailOp, egressAdpOp, egressAdpHandle)
+	sub.RegisterVertex(egressAdpOp, egressAdpHandle)
+	sub.RegisterEdge(tailOp, egressAdpOp, egressAdpHandle)
 	tailOp.SetTarget(egressAdpVid)
 
 	// Create feedback operator
-	feedbackTaskCh := make(chan request.Request, constants.ChanCapacity)
-	feedbackAckCh := make(chan request.Request, constants.ChanCapacity)
+	feedbackOp := sub.newFeedback()
+
+	sub.RegisterEdge(egressAdpOp, feedbackOp, feedbackOp.handle)
+	egressAdpOp.SetTarget2(feedbackOp.Id())
 
-	feedbackHandle := handles.NewLocalVertexHandle(feedbackTaskCh, feedbackAckCh)
+	sub.RegisterEdge(feedbackOp, ingressAdpOp, ingressAdpHandle2)
+	feedbackOp.SetTarget(ingressAdpVid)
 
-	feedbackVid := s.GenerateVID()
+	// Create egress operator
+	return sub.Leave(egressAdpOp)
+}
 
-	feedbackOp := &FeedbackOpCore{
-		OpCore: NewOpCore(feedbackVid, vertex.Type_Feedback, s),
-		handle: feedbackHandle,
+// Iterate builds a bulk synchronous loop on top of Loop. The messages of an
+// iteration form the loop variable, and f is invoked once the iteration is complete,
+// with the full loop variable, to compute the loop variable of the next iteration.
+// Options such as WithMaxIterations apply to the underlying loop.
+func (op *OpCore) Iterate(f IterateCallback, opts ...LoopOption) EgressOp {
+	exits := &iterateExits{
+		counts: make(map[string]int),
 	}
+	return op.Loop(
+		func(ups Operator) Operator {
+			s := ups.AsScope()
 
-	s.RegisterVertex(feedbackOp, feedbackHandle)
-	s.RegisterEdge(egressAdpOp, feedbackOp, feedbackHandle)
-	egressAdpOp.SetTarget2(feedbackVid)
+			taskCh := make(chan request.Request, constants.ChanCapacity)
 
-	s.RegisterEdge(feedbackOp, ingressAdpOp, ingressAdpHandle2)
-	feedbackOp.SetTarget(ingressAdpVid)
+			ackCh := make(chan request.Request, constants.ChanCapacity)
 
-	// Create egress adapter
-	egressTaskCh := make(chan request.Request, constants.ChanCapacity)
-	egressAckCh := make(chan request.Request, constants.ChanCapacity)
+			handle := handles.NewLocalVertexHandle(taskCh, ackCh)
 
-	egressHandle := handles.NewLocalVertexHandle(egressTaskCh, egressAckCh)
+			vid := s.GenerateVID()
 
-	egressVid := s.GenerateVID()
+			v := &IterateOpCore{
+				OpCore:  NewOpCore(vid, vertex.Type_Iterate, s),
+				handle:  handle,
+				f:       f,
+				exits:   exits,
+				buffers: make(map[string]*tsBuffer),
+			}
 
-	egressOp := &EgressOpCore{
-		OpCore: NewOpCore(egressVid, vertex.Type_Egress, s),
-		handle: egressHandle,
-	}
+			s.RegisterVertex(v, handle)
+			s.RegisterEdge(ups, v, handle)
+			ups.SetTarget(vid)
 
-	s.RegisterVertex(egressOp, egressHandle)
-	s.RegisterEdge(egressAdpOp, egressOp, egressHandle)
-	egressAdpOp.SetTarget(egressVid)
-	return egressOp
+			return v
+		},
+		func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+			return !exits.take(ts), nil
+		},
+		opts...,
+	)
 }
 
 // ================ Imple some core functions for common use case ============= //
 
+// coreMetrics returns the metrics of the operator.
+func (op *OpCore) coreMetrics() *opMetrics {
+	op.metricsOnce.Do(func() {
+		op.metrics = newOpMetrics(op)
+	})
+	return op.metrics
+}
+
+// coreLogger returns the logger of the scope with the id and type of the vertex as fields.
+func (op *OpCore) coreLogger() *zap.Logger {
+	op.loggerOnce.Do(func() {
+		op.logger = op.Logger().With(
+			zap.Int("vertex", int(op.id)),
+			zap.String("type", op.typ.String()),
+		)
+		if op.name != "" {
+			op.logger = op.logger.With(zap.String("name", op.name))
+		}
+	})
+	return op.logger
+}
+
+// coreHandle runs f to handle a request taken from the channel of the vertex,
+// and reports the request into the metrics and the event log of the scope.
+func (op *OpCore) coreHandle(req *request.Request, f func() error) error {
+	m := op.coreMetrics()
+	if req.Type == request.Type_OnRecv && op.Events().Enabled() {
+		op.Events().Emit(events.Event{
+			Kind:   events.Kind_MessageReceived,
+			Worker: op.Index(),
+			Vertex: op.id,
+			Src:    req.Edge.GetSrc(),
+			Target: req.Edge.GetTarget(),
+			Ts:     timestamp.CopyTimestampFrom(&req.Ts),
+		})
+	}
+	start := time.Now()
+	err := op.coreTrace(req.Type.String(), &req.Ts, f)
+	m.latency.Observe(time.Since(start).Seconds())
+	if req.Type == request.Type_OnRecv {
+		m.received.Inc()
+	} else if req.Type == request.Type_OnNotify {
+		m.notified.Inc()
+	}
+	if err != nil {
+		m.errors.Inc()
+	}
+	return err
+}
+
 func (op *OpCore) coreSendBy(
 	e edge.Edge,
 	msg *request.Message,
@@ -272,9 +520,111 @@ func (op *OpCore) coreSendBy(
 	if err := op.GetWorkerHandle().Send(&req); err != nil {
 		return err
 	}
+	op.coreSent()
 	return nil
 }
 
+// coreSent counts a message sent by the operator.
+func (op *OpCore) coreSent() {
+	op.sentCount++
+	op.coreMetrics().sent.Inc()
+}
+
+// coreTrace runs f, which does some work of the vertex at timestamp ts,
+// and records it as a span if the scope has a tracer.
+func (op *OpCore) coreTrace(name string, ts *timestamp.Timestamp, f func() error) error {
+	tracer := op.Tracer()
+	if !tracer.Enabled() {
+		return f()
+	}
+	sent := op.sentCount
+	start := time.Now()
+	err := f()
+	tracer.Record(tracing.Span{
+		Name:     name,
+		Worker:   op.Index(),
+		Vertex:   op.id,
+		Start:    start,
+		End:      time.Now(),
+		Ts:       timestamp.CopyTimestampFrom(ts),
+		Messages: op.sentCount - sent,
+	})
+	return err
+}
+
+// coreSendIter drains the iterator returned by a callback and sends
+// every message in it along edge e with timestamp ts.
+func (op *OpCore) coreSendIter(
+	iter iterator.Iterator[*request.Message],
+	e edge.Edge,
+	ts timestamp.Timestamp,
+	handle handles.VertexHandle,
+) error {
+	if iter == nil {
+		return nil
+	}
+	for {
+		flag, err := iter.HasElement()
+		if err != nil {
+			return err
+		}
+		if !flag {
+			return nil
+		}
+		m, err := iter.Iter()
+		if err != nil {
+			return err
+		}
+		if err := op.coreSendBy(e, m, ts, handle); err != nil {
+			return err
+		}
+	}
+}
+
+// coreRetire decrements the occurrence count of the pointstamp that e carried
+// after the work it triggered is done. Outputs are incremented before the input
+// is decremented, so the frontier never passes a message that is still producing
+// results. The decrement happens even if the work failed so that a failing
+// callback does not hold back the frontier forever.
+func (op *OpCore) coreRetire(
+	e edge.Edge,
+	ts timestamp.Timestamp,
+	handle handles.VertexHandle,
+	workErr error,
+) error {
+	if err := op.coreDecreOC(e, ts, handle); err != nil {
+		return err
+	}
+	return workErr
+}
+
+// coreNotifyAt asks the worker for a notification once the frontier passes ts
+// at this vertex. The worker holds a vertex pointstamp on behalf of the request,
+// which is retired with coreNotified after the notification is handled.
+func (op *OpCore) coreNotifyAt(
+	ts timestamp.Timestamp,
+	handle handles.VertexHandle,
+) error {
+	req := request.Request{
+		Type: request.Type_NotifyAt,
+		Edge: edge.NewEdge(op.id, op.id),
+		Msg:  *request.NewMessage([]byte{}),
+		Ts:   ts,
+	}
+	if err := op.GetWorkerHandle().Send(&req); err != nil {
+		return err
+	}
+	return op.coreWaitAck(handle)
+}
+
+// coreNotified retires the pointstamp held for a delivered notification.
+func (op *OpCore) coreNotified(
+	ts timestamp.Timestamp,
+	handle handles.VertexHandle,
+) error {
+	return op.coreDecreOC(edge.NewEdge(op.id, op.id), ts, handle)
+}
+
 func (op *OpCore) coreIncreOC(
 	e edge.Edge,
 	ts timestamp.Timestamp,
@@ -290,8 +640,7 @@ func (op *OpCore) coreIncreOC(
 	if err := op.GetWorkerHandle().Send(&incReq); err != nil {
 		return err
 	}
-	<-handle.AckRecv()
-	return nil
+	return op.coreWaitAck(handle)
 }
 
 func (op *OpCore) coreDecreOC(
@@ -309,14 +658,53 @@ func (op *OpCore) coreDecreOC(
 	if err := op.GetWorkerHandle().Send(&req); err != nil {
 		return err
 	}
-	<-handle.AckRecv()
-	return nil
+	return op.coreWaitAck(handle)
 }
 
+// coreWaitAck waits for the worker to ack the last request.
+// If the computation is aborted the ack may never come, so it gives up once the scope is done.
+func (op *OpCore) coreWaitAck(handle handles.VertexHandle) error {
+	atomic.StoreInt32(&op.blocked, 1)
+	defer atomic.StoreInt32(&op.blocked, 0)
+	select {
+	case <-handle.AckRecv():
+		return nil
+	case <-op.Done():
+		return errors.New("scope is done while waiting for ack")
+	}
+}
+
+// Blocked tells whether the vertex is waiting for the worker to ack a request.
+func (op *OpCore) Blocked() bool {
+	return atomic.LoadInt32(&op.blocked) == 1
+}
+
+// tsUpdate records the latest timestamp seen by the operator.
+// Messages of different iterations or from different entry streams
+// may interleave, so an earlier timestamp is not an error here.
+func (op *OpCore) tsUpdate(ts *timestamp.Timestamp) {
+	op.tsMu.Lock()
+	defer op.tsMu.Unlock()
+	if timestamp.LE(&op.currTs, ts) {
+		op.currTs = *timestamp.CopyTimestampFrom(ts)
+	}
+}
+
+// tsCheckAndUpdate is used by operators which require their timestamps
+// to never go backwards, such as the input operator.
 func (op *OpCore) tsCheckAndUpdate(ts *timestamp.Timestamp) error {
+	op.tsMu.Lock()
+	defer op.tsMu.Unlock()
 	if !(timestamp.LE(&op.currTs, ts)) {
-		return errors.New("cannot accept an earlier timestamp from inspect operator")
+		return errors.New("cannot accept an earlier timestamp in operator")
 	}
-	op.currTs = *ts
+	op.currTs = *timestamp.CopyTimestampFrom(ts)
 	return nil
 }
+
+// CurrentTimestamp returns the latest timestamp seen by the operator.
+func (op *OpCore) CurrentTimestamp() timestamp.Timestamp {
+	op.tsMu.Lock()
+	defer op.tsMu.Unlock()
+	return *timestamp.CopyTimestampFrom(&op.currTs)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stepneko/neko-dataflow/edge"
 	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
@@ -28,11 +29,26 @@ type EgressAdapterOp interface {
 	DoubleOutput
 }
 
+// fixedPointState is the state of a fixed point loop for one timestamp outside the loop.
+type fixedPointState struct {
+	// Distinct messages seen so far, in the order they were first seen.
+	msgs []*request.Message
+	seen map[string]bool
+	// Number of new messages fed back in each iteration.
+	fresh map[int]int
+}
+
 type EgressAdapterOpCore struct {
 	*OpCore
 	handle  InputHandle
 	target2 vertex.Id
 	f       FilterCallback
+	config  *loopConfig
+
+	// States of fixed point loops keyed by the hash of the timestamp outside the loop.
+	fixedPoints map[string]*fixedPointState
+	// Notifications requested and not yet delivered, keyed by the hash of the timestamp.
+	requested map[string]bool
 }
 
 func (op *EgressAdapterOpCore) Start(wg *sync.WaitGroup) error {
@@ -42,22 +58,29 @@ func (op *EgressAdapterOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *EgressAdapterOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
 func (op *EgressAdapterOpCore) handleReq(req *request.Request) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		return op.OnRecv(edge, &msg, ts)
@@ -69,33 +92,115 @@ func (op *EgressAdapterOpCore) handleReq(req *request.Request) error {
 }
 
 func (op *EgressAdapterOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle); err != nil {
-		return err
+	flag := true
+	var err error
+	if op.f != nil {
+		flag, err = op.f(e, msg, ts)
+	}
+	if err == nil {
+		// If loop boolean flag is true, the dataflow should move back through the loop,
+		// via target2 which is the feedback operator
+		if flag && op.config.fixedPoint {
+			err = op.fixedPointRecv(msg, ts)
+		} else if flag && op.loopBack(ts) {
+			err = op.SendBy(edge.NewEdge(op.id, op.target2), msg, ts)
+			// Otherwise dataflow should move via target which is the EgressAdapter operator
+		} else {
+			err = op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
+		}
 	}
+	return op.coreRetire(e, ts, op.handle, err)
+}
+
+// OnNotify is only requested in fixed point mode, and is delivered once
+// all messages of an iteration went through the loop body.
+func (op *EgressAdapterOpCore) OnNotify(ts timestamp.Timestamp) error {
+	delete(op.requested, utils.Hash(ts))
+	err := op.fixedPointNotify(ts)
+	if notifiedErr := op.coreNotified(ts, op.handle); notifiedErr != nil {
+		return notifiedErr
+	}
+	return err
+}
 
-	flag, err := op.f(e, msg, ts)
-	if err != nil {
+// loopBack checks the iteration bound of the loop.
+func (op *EgressAdapterOpCore) loopBack(ts timestamp.Timestamp) bool {
+	if op.config.maxIterations <= 0 {
+		return true
+	}
+	return iterationOf(ts)+1 < op.config.maxIterations
+}
+
+func (op *EgressAdapterOpCore) fixedPointRecv(msg *request.Message, ts timestamp.Timestamp) error {
+	if err := op.requestNotify(ts); err != nil {
 		return err
 	}
 
-	// If loop boolean flag is true, the dataflow should move back through the loop,
-	// via target2 which is the feedback operator
-	if flag {
-		if err := op.SendBy(edge.NewEdge(op.id, op.target2), msg, ts); err != nil {
-			return err
+	key := outerHash(ts)
+	state, exist := op.fixedPoints[key]
+	if !exist {
+		state = &fixedPointState{
+			msgs:  []*request.Message{},
+			seen:  make(map[string]bool),
+			fresh: make(map[int]int),
 		}
+		op.fixedPoints[key] = state
+	}
+
+	msgHash := utils.Hash(msg.ToString())
+	if state.seen[msgHash] {
 		return nil
-		// Otherwise dataflow should move via target which is the EgressAdapter operator
-	} else {
-		if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts); err != nil {
+	}
+	state.seen[msgHash] = true
+	state.msgs = append(state.msgs, msg)
+
+	if !op.loopBack(ts) {
+		return nil
+	}
+	state.fresh[iterationOf(ts)] += 1
+	return op.SendBy(edge.NewEdge(op.id, op.target2), msg, ts)
+}
+
+// fixedPointNotify checks whether the completed iteration produced new messages.
+// If not, the loop reached its fixed point for the timestamp, and all messages
+// seen are emitted out of the loop.
+func (op *EgressAdapterOpCore) fixedPointNotify(ts timestamp.Timestamp) error {
+	key := outerHash(ts)
+	state, exist := op.fixedPoints[key]
+	if !exist {
+		return nil
+	}
+
+	iteration := iterationOf(ts)
+	fresh := state.fresh[iteration]
+	delete(state.fresh, iteration)
+
+	if fresh > 0 {
+		// Watch the next iteration as well, even if it turns out
+		// that no message of it reaches this vertex.
+		nextTs := timestamp.CopyTimestampFrom(&ts)
+		if err := timestamp.HandleTimestamp(vertex.Type_Feedback, nextTs); err != nil {
 			return err
 		}
-		return nil
+		return op.requestNotify(*nextTs)
 	}
+
+	delete(op.fixedPoints, key)
+	return op.coreSendIter(
+		iterator.IterFromArray(state.msgs),
+		edge.NewEdge(op.id, op.target),
+		ts,
+		op.handle,
+	)
 }
 
-func (op *EgressAdapterOpCore) OnNotify(ts timestamp.Timestamp) error {
-	return nil
+func (op *EgressAdapterOpCore) requestNotify(ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	if op.requested[key] {
+		return nil
+	}
+	op.requested[key] = true
+	return op.NotifyAt(ts)
 }
 
 func (op *EgressAdapterOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
@@ -103,9 +208,29 @@ func (op *EgressAdapterOpCore) SendBy(e edge.Edge, msg *request.Message, ts time
 }
 
 func (op *EgressAdapterOpCore) NotifyAt(ts timestamp.Timestamp) error {
-	return nil
+	return op.coreNotifyAt(ts, op.handle)
 }
 
 func (op *EgressAdapterOpCore) SetTarget2(vid vertex.Id) {
 	op.target2 = vid
 }
+
+// iterationOf returns the innermost counter of the timestamp,
+// which is the iteration counter of the innermost loop.
+func iterationOf(ts timestamp.Timestamp) int {
+	l := len(ts.Counters)
+	if l == 0 {
+		return 0
+	}
+	return ts.Counters[l-1]
+}
+
+// outerHash hashes the timestamp without its innermost counter,
+// so that all iterations of a loop share the same hash.
+func outerHash(ts timestamp.Timestamp) string {
+	l := len(ts.Counters)
+	if l == 0 {
+		return utils.Hash(ts)
+	}
+	return utils.Hash(*timestamp.NewTimestampWithParams(ts.Epoch, ts.Counters[:l-1]))
+}
@@ -0,0 +1,146 @@
+package operators
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+type ExchangeHandle interface {
+	handles.VertexHandle
+}
+
+type ExchangeOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+}
+
+// ExchangeOpCore routes each message to the instance of the downstream vertex
+// on the worker owning the key of the message. Every worker builds the same dataflow,
+// so the downstream vertex has the same id on all workers.
+type ExchangeOpCore struct {
+	*OpCore
+	handle ExchangeHandle
+	key    KeyCallback
+}
+
+func (op *ExchangeOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *ExchangeOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *ExchangeOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *ExchangeOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	peer, err := op.route(msg)
+	if err == nil {
+		err = op.sendTo(peer, edge.NewEdge(op.id, op.target), msg, ts)
+	}
+	return op.coreRetire(e, ts, op.handle, err)
+}
+
+func (op *ExchangeOpCore) OnNotify(ts timestamp.Timestamp) error {
+	return nil
+}
+
+func (op *ExchangeOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *ExchangeOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return nil
+}
+
+// route returns the index of the worker owning the key of the message.
+// Without a key the message stays on the local worker.
+func (op *ExchangeOpCore) route(msg *request.Message) (int, error) {
+	peers := op.Peers()
+	if peers <= 1 || op.key == nil {
+		return op.Index(), nil
+	}
+	key, err := op.key(msg)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(peers)), nil
+}
+
+// sendTo sends the message to the downstream vertex on the given worker.
+// The occurrence count of the edge is incremented before the message leaves,
+// and the receiving vertex decrements it after processing, as for a local edge.
+// The progress tracker is shared between workers so both updates apply
+// to the same pointstamp.
+func (op *ExchangeOpCore) sendTo(
+	peer int,
+	e edge.Edge,
+	msg *request.Message,
+	ts timestamp.Timestamp,
+) error {
+	if e.GetTarget() == vertex.Id_Nil {
+		return nil
+	}
+
+	peerHandle, err := op.GetPeerHandle(peer)
+	if err != nil {
+		return err
+	}
+
+	if err := op.coreIncreOC(e, ts, op.handle); err != nil {
+		return err
+	}
+
+	req := request.Request{
+		Type: request.Type_SendBy,
+		Edge: e,
+		Msg:  *msg,
+		Ts:   ts,
+	}
+	if err := peerHandle.Send(&req); err != nil {
+		return err
+	}
+	op.coreSent()
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
 	"github.com/stepneko/neko-dataflow/vertex"
 )
 
@@ -25,6 +24,7 @@ type FeedbackOp interface {
 	scope.Scope
 	Operator
 	SingleInput
+	Connect(src Operator) error
 }
 
 type FeedbackOpCore struct {
@@ -39,22 +39,29 @@ func (op *FeedbackOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *FeedbackOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
 func (op *FeedbackOpCore) handleReq(req *request.Request) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		return op.OnRecv(edge, &msg, ts)
@@ -66,19 +73,12 @@ func (op *FeedbackOpCore) handleReq(req *request.Request) error {
 }
 
 func (op *FeedbackOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle); err != nil {
-		return err
-	}
-
 	newTs := timestamp.CopyTimestampFrom(&ts)
-	if err := timestamp.HandleTimestamp(vertex.Type_Feedback, newTs); err != nil {
-		return err
+	err := timestamp.HandleTimestamp(vertex.Type_Feedback, newTs)
+	if err == nil {
+		err = op.SendBy(edge.NewEdge(op.id, op.target), msg, *newTs)
 	}
-
-	if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, *newTs); err != nil {
-		return err
-	}
-	return nil
+	return op.coreRetire(e, ts, op.handle, err)
 }
 
 func (op *FeedbackOpCore) OnNotify(ts timestamp.Timestamp) error {
@@ -92,3 +92,12 @@ func (op *FeedbackOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp
 func (op *FeedbackOpCore) NotifyAt(ts timestamp.Timestamp) error {
 	return nil
 }
+
+// Connect feeds the stream of src back to the beginning of the loop.
+func (op *FeedbackOpCore) Connect(src Operator) error {
+	if err := op.RegisterEdge(src, op, op.handle); err != nil {
+		return err
+	}
+	src.SetTarget(op.id)
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
+	"github.com/stepneko/neko-dataflow/vertex"
 )
 
 type FilterHandle interface {
@@ -39,22 +39,37 @@ func (op *FilterOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *FilterOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *FilterOpCore) Fuse(next vertex.Vertex) (vertex.Vertex, bool) {
+	return newFusedOp(op.OpCore, op.handle, op.stages(), next)
+}
+
+func (op *FilterOpCore) stages() []fusedStage {
+	return []fusedStage{{id: op.id, f: filterStage(op.f)}}
+}
+
 func (op *FilterOpCore) handleReq(req *request.Request) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		return op.OnRecv(edge, &msg, ts)
@@ -66,23 +81,11 @@ func (op *FilterOpCore) handleReq(req *request.Request) error {
 }
 
 func (op *FilterOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle); err != nil {
-		return err
-	}
-
 	flag, err := op.f(e, msg, ts)
-	if err != nil {
-		return err
-	}
-
-	if !flag {
-		return nil
+	if err == nil && flag {
+		err = op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
 	}
-
-	if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts); err != nil {
-		return err
-	}
-	return nil
+	return op.coreRetire(e, ts, op.handle, err)
 }
 
 func (op *FilterOpCore) OnNotify(ts timestamp.Timestamp) error {
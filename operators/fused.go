@@ -0,0 +1,174 @@
+package operators
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// fusedStage is the work of one of the vertices in a fused chain.
+type fusedStage struct {
+	id vertex.Id
+	f  DataCallback
+}
+
+// fusable is implemented by operators which can be part of a fused chain.
+type fusable interface {
+	vertex.Vertex
+	getTarget() vertex.Id
+	stages() []fusedStage
+}
+
+// FusedOpCore runs the callbacks of a chain of operators back to back.
+// It takes the place of the first operator of the chain, so messages arrive on
+// the input edge of the first operator and leave on the output edge of the fused vertex.
+// Messages between the operators of the chain are never sent, so they need no pointstamps.
+type FusedOpCore struct {
+	*OpCore
+	handle    handles.VertexHandle
+	fusedWith []fusedStage
+}
+
+// newFusedOp creates the vertex running the stages of head and then the stages of next.
+func newFusedOp(
+	head *OpCore,
+	handle handles.VertexHandle,
+	stages []fusedStage,
+	next vertex.Vertex,
+) (vertex.Vertex, bool) {
+	n, ok := next.(fusable)
+	if !ok {
+		return nil, false
+	}
+	op := &FusedOpCore{
+		OpCore:    NewOpCore(head.id, vertex.Type_Fused, head.Scope),
+		handle:    handle,
+		fusedWith: append(append([]fusedStage{}, stages...), n.stages()...),
+	}
+	op.name = fusedName(head.name, n.Label())
+	op.SetTarget(n.getTarget())
+	return op, true
+}
+
+func (op *FusedOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *FusedOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *FusedOpCore) Fuse(next vertex.Vertex) (vertex.Vertex, bool) {
+	return newFusedOp(op.OpCore, op.handle, op.fusedWith, next)
+}
+
+func (op *FusedOpCore) stages() []fusedStage {
+	return op.fusedWith
+}
+
+func (op *FusedOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *FusedOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	err := op.runStage(0, e, msg, ts)
+	return op.coreRetire(e, ts, op.handle, err)
+}
+
+// runStage runs stage i on the message and passes every result to the next stage.
+// Results of the last stage are sent to the target of the chain.
+// Each stage sees the edge it would have received the message on without fusion.
+func (op *FusedOpCore) runStage(i int, e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	iter, err := op.fusedWith[i].f(e, msg, ts)
+	if err != nil {
+		return err
+	}
+	if i == len(op.fusedWith)-1 {
+		return op.coreSendIter(iter, edge.NewEdge(op.id, op.target), ts, op.handle)
+	}
+	if iter == nil {
+		return nil
+	}
+	next := edge.NewEdge(op.fusedWith[i].id, op.fusedWith[i+1].id)
+	for {
+		flag, err := iter.HasElement()
+		if err != nil {
+			return err
+		}
+		if !flag {
+			return nil
+		}
+		m, err := iter.Iter()
+		if err != nil {
+			return err
+		}
+		if err := op.runStage(i+1, next, m, ts); err != nil {
+			return err
+		}
+	}
+}
+
+func (op *FusedOpCore) OnNotify(ts timestamp.Timestamp) error {
+	return nil
+}
+
+func (op *FusedOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *FusedOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return nil
+}
+
+// filterStage turns a filter callback into a stage emitting the message if it passes.
+func filterStage(f FilterCallback) DataCallback {
+	return func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+		flag, err := f(e, msg, ts)
+		if err != nil || !flag {
+			return nil, err
+		}
+		return iterator.IterFromSingleton(msg), nil
+	}
+}
+
+// fusedName names a fused vertex after the names of the vertices it runs, such as parse+clean.
+func fusedName(head string, next string) string {
+	if head == "" || next == "" {
+		return head + next
+	}
+	return head + "+" + next
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
 	"github.com/stepneko/neko-dataflow/vertex"
 )
 
@@ -39,22 +38,29 @@ func (op *IngressOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *IngressOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
 func (op *IngressOpCore) handleReq(req *request.Request) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		return op.OnRecv(edge, &msg, ts)
@@ -66,19 +72,12 @@ func (op *IngressOpCore) handleReq(req *request.Request) error {
 }
 
 func (op *IngressOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle); err != nil {
-		return err
-	}
-
 	newTs := timestamp.CopyTimestampFrom(&ts)
-	if err := timestamp.HandleTimestamp(vertex.Type_Ingress, newTs); err != nil {
-		return err
-	}
-
-	if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, *newTs); err != nil {
-		return err
+	err := timestamp.HandleTimestamp(vertex.Type_Ingress, newTs)
+	if err == nil {
+		err = op.SendBy(edge.NewEdge(op.id, op.target), msg, *newTs)
 	}
-	return nil
+	return op.coreRetire(e, ts, op.handle, err)
 }
 
 func (op *IngressOpCore) OnNotify(ts timestamp.Timestamp) error {
@@ -9,7 +9,6 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
 )
 
 type IngressAdapterHandle interface {
@@ -39,26 +38,35 @@ func (op *IngressAdapterOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle1.MsgRecv():
-			if err := op.handleReq(&req, BinaryType_Left); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Left) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		case req := <-op.handle2.MsgRecv():
-			if err := op.handleReq(&req, BinaryType_Right); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Right) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *IngressAdapterOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle1.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Left) })
+	case req := <-op.handle2.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req, BinaryType_Right) })
+	default:
+		return false, nil
+	}
+}
+
 func (op *IngressAdapterOpCore) handleReq(req *request.Request, bt BinaryType) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		if bt == BinaryType_Left {
@@ -82,14 +90,8 @@ func (op *IngressAdapterOpCore) handleReq(req *request.Request, bt BinaryType) e
 }
 
 func (op *IngressAdapterOpCore) OnRecv1(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle1); err != nil {
-		return err
-	}
-
-	if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts); err != nil {
-		return err
-	}
-	return nil
+	err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
+	return op.coreRetire(e, ts, op.handle1, err)
 }
 
 func (op *IngressAdapterOpCore) OnRecv2(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
@@ -100,14 +102,8 @@ func (op *IngressAdapterOpCore) OnRecv2(e edge.Edge, msg *request.Message, ts ti
 	//
 	// Actually from the worker side, the worker picks vertex handle by map[src, src] or
 	// map[target, target], rather than map[src, target], therefore only handle1 will be picked.
-	if err := op.coreDecreOC(e, ts, op.handle1); err != nil {
-		return err
-	}
-
-	if err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts); err != nil {
-		return err
-	}
-	return nil
+	err := op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
+	return op.coreRetire(e, ts, op.handle1, err)
 }
 
 func (op *IngressAdapterOpCore) OnNotify1(ts timestamp.Timestamp) error {
@@ -2,6 +2,7 @@ package operators
 
 import (
 	"sync"
+	"time"
 
 	"github.com/stepneko/neko-dataflow/constants"
 	"github.com/stepneko/neko-dataflow/edge"
@@ -9,7 +10,6 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
 	"github.com/stepneko/neko-dataflow/vertex"
 )
 
@@ -31,10 +31,16 @@ type InputOpCore struct {
 	*OpCore
 	handle  InputHandle
 	inputCh chan request.InputDatum
+	// epochTs is the timestamp of the pointstamp the input holds at its own
+	// location. Nothing downstream can be notified at or after it.
+	epochTs timestamp.Timestamp
+	closed  bool
+	// Probe the input records the time it opens each epoch into, nil if none.
+	probe *LatencyProbe
 }
 
 // NewInput creates input operator from scope
-func NewInput(s scope.Scope, inputCh chan request.InputDatum) InputOp {
+func NewInput(s scope.Scope, inputCh chan request.InputDatum, opts ...OpOption) InputOp {
 	taskCh := make(chan request.Request, constants.ChanCapacity)
 	ackCh := make(chan request.Request, constants.ChanCapacity)
 
@@ -43,9 +49,12 @@ func NewInput(s scope.Scope, inputCh chan request.InputDatum) InputOp {
 	vid := s.GenerateVID()
 
 	v := &InputOpCore{
-		OpCore:  NewOpCore(vid, vertex.Type_Input, s),
+		OpCore:  NewOpCore(vid, vertex.Type_Input, s).withOptions(opts),
 		handle:  handle,
 		inputCh: inputCh,
+		epochTs: *timestamp.NewTimestamp(),
+		closed:  false,
+		probe:   newOpConfig(opts).probe,
 	}
 
 	s.RegisterVertex(v, handle)
@@ -59,14 +68,34 @@ func (op *InputOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
-		case inDatum := <-op.inputCh:
-			if err := op.handleInput(inDatum); err != nil {
-				utils.Logger().Error(err.Error())
+		case inDatum, ok := <-op.inputCh:
+			if !ok {
+				if err := op.close(); err != nil {
+					op.coreLogger().Error(err.Error())
+				}
+				continue
 			}
+			if err := op.traceInput(inDatum); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *InputOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	case inDatum, ok := <-op.inputCh:
+		if !ok {
+			return true, op.close()
 		}
+		return true, op.traceInput(inDatum)
+	default:
+		return false, nil
 	}
 }
 
@@ -76,6 +105,9 @@ func (op *InputOpCore) handleReq(req *request.Request) error {
 	return nil
 }
 
+// handleInput sends the input datum to downstream. A datum with a later epoch
+// marks all earlier epochs complete, and a datum with nil message only advances
+// the epoch without sending anything.
 func (op *InputOpCore) handleInput(inDatum request.InputDatum) error {
 	msg := inDatum.Msg()
 	ts := inDatum.Ts()
@@ -83,6 +115,17 @@ func (op *InputOpCore) handleInput(inDatum request.InputDatum) error {
 	if err := op.tsCheckAndUpdate(&ts); err != nil {
 		return err
 	}
+	op.probe.open(ts.Epoch, time.Now())
+
+	if ts.Epoch > op.epochTs.Epoch {
+		if err := op.advance(ts.Epoch); err != nil {
+			return err
+		}
+	}
+
+	if msg == nil {
+		return nil
+	}
 
 	e := edge.NewEdge(op.id, op.target)
 	if err := op.SendBy(e, msg, ts); err != nil {
@@ -91,6 +134,40 @@ func (op *InputOpCore) handleInput(inDatum request.InputDatum) error {
 	return nil
 }
 
+// traceInput handles the input datum as a span of the input vertex.
+func (op *InputOpCore) traceInput(inDatum request.InputDatum) error {
+	ts := inDatum.Ts()
+	return op.coreTrace("Input", &ts, func() error { return op.handleInput(inDatum) })
+}
+
+// advance moves the pointstamp held by the input to the given epoch.
+// According to the paper, the new pointstamp is added before the old one
+// is removed, so that the frontier only moves forward.
+func (op *InputOpCore) advance(epoch int) error {
+	newTs := timestamp.CopyTimestampFrom(&op.epochTs)
+	newTs.Epoch = epoch
+	e := edge.NewEdge(op.id, op.id)
+	if err := op.coreIncreOC(e, *newTs, op.handle); err != nil {
+		return err
+	}
+	if err := op.coreDecreOC(e, op.epochTs, op.handle); err != nil {
+		return err
+	}
+	op.epochTs = *newTs
+	return nil
+}
+
+// close removes the pointstamp held by the input once the input channel is closed,
+// allowing all events downstream of the input to eventually drain.
+func (op *InputOpCore) close() error {
+	op.inputCh = nil
+	if op.closed {
+		return nil
+	}
+	op.closed = true
+	return op.coreDecreOC(edge.NewEdge(op.id, op.id), op.epochTs, op.handle)
+}
+
 func (op *InputOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
 	return nil
 }
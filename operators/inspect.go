@@ -9,7 +9,7 @@ import (
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
-	"github.com/stepneko/neko-dataflow/utils"
+	"github.com/stepneko/neko-dataflow/vertex"
 )
 
 type InspectHandle interface {
@@ -39,22 +39,37 @@ func (op *InspectOpCore) Start(wg *sync.WaitGroup) error {
 		case <-op.Done():
 			return nil
 		case req := <-op.handle.MsgRecv():
-			if err := op.handleReq(&req); err != nil {
-				utils.Logger().Error(err.Error())
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
 			}
 		}
 	}
 }
 
+func (op *InspectOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *InspectOpCore) Fuse(next vertex.Vertex) (vertex.Vertex, bool) {
+	return newFusedOp(op.OpCore, op.handle, op.stages(), next)
+}
+
+func (op *InspectOpCore) stages() []fusedStage {
+	return []fusedStage{{id: op.id, f: op.f}}
+}
+
 func (op *InspectOpCore) handleReq(req *request.Request) error {
 	typ := req.Type
 	edge := req.Edge
 	msg := req.Msg
 	ts := req.Ts
 
-	if err := op.tsCheckAndUpdate(&ts); err != nil {
-		return err
-	}
+	op.tsUpdate(&ts)
 
 	if typ == request.Type_OnRecv {
 		return op.OnRecv(edge, &msg, ts)
@@ -66,36 +81,12 @@ func (op *InspectOpCore) handleReq(req *request.Request) error {
 }
 
 func (op *InspectOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
-	if err := op.coreDecreOC(e, ts, op.handle); err != nil {
-		return err
-	}
-
 	// Send the result message to next target to continue the dataflow
 	iter, err := op.f(e, msg, ts)
-	if err != nil {
-		return err
-	}
-
-	if iter == nil {
-		return nil
-	}
-
-	for {
-		flag, err := iter.HasElement()
-		if err != nil {
-			return err
-		}
-		if !flag {
-			return nil
-		}
-		m, err := iter.Iter()
-		if err != nil {
-			return err
-		}
-		if err := op.SendBy(edge.NewEdge(op.id, op.target), m, ts); err != nil {
-			return err
-		}
+	if err == nil {
+		err = op.coreSendIter(iter, edge.NewEdge(op.id, op.target), ts, op.handle)
 	}
+	return op.coreRetire(e, ts, op.handle, err)
 }
 
 func (op *InspectOpCore) OnNotify(ts timestamp.Timestamp) error {
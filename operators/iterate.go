@@ -0,0 +1,197 @@
+package operators
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+// IterateCallback computes one iteration of Iterate. It is invoked once per
+// iteration with all messages of the loop variable in that iteration, and
+// returns the loop variable of the next iteration. If done is true, the
+// returned messages leave the loop as the result instead.
+type IterateCallback func(
+	iteration int,
+	ts timestamp.Timestamp,
+	msgs []*request.Message,
+) (iter iterator.Iterator[*request.Message], done bool, err error)
+
+type IterateHandle interface {
+	handles.VertexHandle
+}
+
+type IterateOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+}
+
+// iterateExits counts the messages of finished iterations which are still
+// on the way to the loop filter, keyed by the hash of their timestamp.
+// It is shared by the iterate vertex and the loop filter, which run in
+// different goroutines.
+type iterateExits struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (ie *iterateExits) add(ts timestamp.Timestamp, n int) {
+	// An entry with no messages would let the next message with the timestamp leave the loop
+	if n == 0 {
+		return
+	}
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	ie.counts[utils.Hash(ts)] += n
+}
+
+// take tells whether a message with the timestamp should leave the loop.
+func (ie *iterateExits) take(ts timestamp.Timestamp) bool {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	key := utils.Hash(ts)
+	n, exist := ie.counts[key]
+	if !exist {
+		return false
+	}
+	if n <= 1 {
+		delete(ie.counts, key)
+	} else {
+		ie.counts[key] = n - 1
+	}
+	return true
+}
+
+type IterateOpCore struct {
+	*OpCore
+	handle IterateHandle
+	f      IterateCallback
+	exits  *iterateExits
+	// Messages buffered per timestamp, keyed by the hash of the timestamp.
+	buffers map[string]*tsBuffer
+}
+
+func (op *IterateOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *IterateOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *IterateOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *IterateOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	if !exist {
+		// First message of this iteration, so ask to be notified
+		// when the whole loop variable of the iteration has arrived.
+		// The buffer is only kept once the notification is requested,
+		// so the next message of the iteration tries again otherwise.
+		if err := op.NotifyAt(ts); err != nil {
+			return op.coreRetire(e, ts, op.handle, err)
+		}
+		buf = &tsBuffer{
+			ts:   ts,
+			msgs: []*request.Message{},
+		}
+		op.buffers[key] = buf
+	}
+	buf.msgs = append(buf.msgs, msg)
+	return op.coreRetire(e, ts, op.handle, nil)
+}
+
+func (op *IterateOpCore) OnNotify(ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	var err error
+	if exist {
+		delete(op.buffers, key)
+		err = op.step(buf)
+	}
+	if notifiedErr := op.coreNotified(ts, op.handle); notifiedErr != nil {
+		return notifiedErr
+	}
+	return err
+}
+
+func (op *IterateOpCore) step(buf *tsBuffer) error {
+	iter, done, err := op.f(iterationOf(buf.ts), buf.ts, buf.msgs)
+	if err != nil {
+		return err
+	}
+	msgs := []*request.Message{}
+	if iter != nil {
+		for {
+			flag, err := iter.HasElement()
+			if err != nil {
+				return err
+			}
+			if !flag {
+				break
+			}
+			m, err := iter.Iter()
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, m)
+		}
+	}
+	if done {
+		// Tell the loop filter before sending, so that it is known
+		// by the time the messages reach the filter.
+		op.exits.add(buf.ts, len(msgs))
+	}
+	return op.coreSendIter(
+		iterator.IterFromArray(msgs),
+		edge.NewEdge(op.id, op.target),
+		buf.ts,
+		op.handle,
+	)
+}
+
+func (op *IterateOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *IterateOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return op.coreNotifyAt(ts, op.handle)
+}
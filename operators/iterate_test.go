@@ -0,0 +1,88 @@
+package operators
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/constants"
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingScope is a scope whose worker fails the first failNotify NotifyAt requests
+// and acks every other request at once.
+type failingScope struct {
+	scope.Scope
+	handle     handles.VertexHandle
+	failNotify int
+	requests   []request.Type
+}
+
+func (s *failingScope) GetWorkerHandle() handles.WorkerHandle {
+	return handles.NewSyncWorkerHandle(func(req *request.Request) error {
+		s.requests = append(s.requests, req.Type)
+		if req.Type == request.Type_NotifyAt && s.failNotify > 0 {
+			s.failNotify -= 1
+			return errors.New("cannot notify")
+		}
+		s.handle.Ack(req)
+		return nil
+	})
+}
+
+func (s *failingScope) Done() <-chan struct{} {
+	return nil
+}
+
+func TestIterateNotifyAtFails(t *testing.T) {
+	handle := handles.NewLocalVertexHandle(
+		make(chan request.Request, constants.ChanCapacity),
+		make(chan request.Request, constants.ChanCapacity),
+	)
+	s := &failingScope{handle: handle, failNotify: 1}
+	op := &IterateOpCore{
+		OpCore:  NewOpCore(2, vertex.Type_Iterate, s),
+		handle:  handle,
+		buffers: make(map[string]*tsBuffer),
+	}
+	e := edge.NewEdge(1, 2)
+	ts := *timestamp.NewTimestampWithParams(0, []int{0, 0})
+
+	// The message is retired, but no buffer is kept without a notification
+	assert.Error(t, op.OnRecv(e, request.NewMessage([]byte("a")), ts))
+	assert.Equal(t, 0, len(op.buffers))
+
+	// So the next message of the iteration asks again
+	assert.Nil(t, op.OnRecv(e, request.NewMessage([]byte("b")), ts))
+	assert.Equal(t, []request.Type{
+		request.Type_NotifyAt, request.Type_DecreOC,
+		request.Type_NotifyAt, request.Type_DecreOC,
+	}, s.requests)
+	assert.Equal(t, 1, len(op.buffers))
+	for _, buf := range op.buffers {
+		assert.Equal(t, 1, len(buf.msgs))
+		assert.Equal(t, "b", buf.msgs[0].ToString())
+	}
+}
+
+func TestIterateExits(t *testing.T) {
+	exits := &iterateExits{
+		counts: make(map[string]int),
+	}
+	ts := *timestamp.NewTimestampWithParams(0, []int{0, 2})
+
+	// An iteration with no messages leaving the loop keeps messages in the loop
+	exits.add(ts, 0)
+	assert.False(t, exits.take(ts))
+
+	exits.add(ts, 2)
+	assert.True(t, exits.take(ts))
+	assert.True(t, exits.take(ts))
+	assert.False(t, exits.take(ts))
+	assert.Equal(t, 0, len(exits.counts))
+}
@@ -0,0 +1,58 @@
+package operators
+
+// loopConfig holds the options of a loop built by Operator.Loop.
+type loopConfig struct {
+	// maxIterations bounds the number of times a message goes through
+	// the loop body. 0 means no bound.
+	maxIterations int
+	// fixedPoint makes the loop stop for a timestamp once an iteration
+	// produces no new messages.
+	fixedPoint bool
+	// name labels the scope of the loop. Defaults to loop.
+	name string
+}
+
+// LoopOption configures a loop built by Operator.Loop.
+type LoopOption interface {
+	applyLoop(c *loopConfig)
+}
+
+// loopOptionFunc adapts a function to a LoopOption.
+type loopOptionFunc func(c *loopConfig)
+
+func (f loopOptionFunc) applyLoop(c *loopConfig) {
+	f(c)
+}
+
+// WithMaxIterations stops looping a message once it went through the loop body n times,
+// no matter what the loop filter says. The message leaves the loop instead.
+// The bound is checked against the innermost timestamp counter, which the feedback
+// vertex increments in every iteration.
+func WithMaxIterations(n int) LoopOption {
+	return loopOptionFunc(func(c *loopConfig) {
+		c.maxIterations = n
+	})
+}
+
+// WithFixedPoint makes the loop compute a fixed point. Messages produced by the loop body
+// are only fed back if they were not seen before for the same timestamp outside the loop,
+// and once an iteration produces no new messages, the loop emits all distinct messages it
+// has seen for that timestamp. Iterations are detected complete by the progress tracker.
+// If the loop filter is given, messages it rejects leave the loop right away as usual.
+func WithFixedPoint() LoopOption {
+	return loopOptionFunc(func(c *loopConfig) {
+		c.fixedPoint = true
+	})
+}
+
+func newLoopConfig(opts []LoopOption) *loopConfig {
+	c := &loopConfig{
+		maxIterations: 0,
+		fixedPoint:    false,
+		name:          "loop",
+	}
+	for _, opt := range opts {
+		opt.applyLoop(c)
+	}
+	return c
+}
@@ -0,0 +1,57 @@
+package operators
+
+import (
+	"strconv"
+
+	"github.com/stepneko/neko-dataflow/metrics"
+)
+
+// opMetrics are the metrics an operator reports into.
+// All of them are nil if the scope has no metrics registry.
+type opMetrics struct {
+	received *metrics.Counter
+	notified *metrics.Counter
+	sent     *metrics.Counter
+	errors   *metrics.Counter
+	latency  *metrics.Histogram
+}
+
+func newOpMetrics(op *OpCore) *opMetrics {
+	r := op.Metrics()
+	labels := metrics.Labels{
+		"worker": strconv.Itoa(op.Index()),
+		"vertex": strconv.Itoa(int(op.id)),
+		"type":   op.typ.String(),
+	}
+	if op.name != "" {
+		labels["name"] = op.name
+	}
+	return &opMetrics{
+		received: r.Counter(
+			"neko_operator_messages_received_total",
+			"Number of messages received by the operator.",
+			labels,
+		),
+		notified: r.Counter(
+			"neko_operator_notifications_total",
+			"Number of notifications handled by the operator.",
+			labels,
+		),
+		sent: r.Counter(
+			"neko_operator_messages_sent_total",
+			"Number of messages sent by the operator.",
+			labels,
+		),
+		errors: r.Counter(
+			"neko_operator_errors_total",
+			"Number of requests the operator failed to handle.",
+			labels,
+		),
+		latency: r.Histogram(
+			"neko_operator_callback_seconds",
+			"Time the operator takes to handle a request, including its callback.",
+			labels,
+			metrics.LatencyBuckets,
+		),
+	}
+}
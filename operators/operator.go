@@ -4,6 +4,7 @@ import (
 	"github.com/stepneko/neko-dataflow/edge"
 	"github.com/stepneko/neko-dataflow/iterator"
 	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
 	"github.com/stepneko/neko-dataflow/vertex"
 )
@@ -20,13 +21,32 @@ type FilterCallback func(
 	ts timestamp.Timestamp,
 ) (bool, error)
 
+// LessCallback reports whether message a should be ordered before message b.
+type LessCallback func(
+	a *request.Message,
+	b *request.Message,
+) bool
+
+// KeyCallback extracts the grouping key of a message.
+type KeyCallback func(
+	msg *request.Message,
+) (string, error)
+
 type Operator interface {
 	vertex.Vertex
+	AsScope() scope.Scope
 	SetTarget(vid vertex.Id)
-	Binary(other Operator, f1 DataCallback, f2 DataCallback) BinaryOp
-	Inspect(f DataCallback) InspectOp
-	Filter(f FilterCallback) FilterOp
-	Loop(dataF func(ups Operator) Operator, filterF FilterCallback) EgressOp
+	Binary(other Operator, f1 DataCallback, f2 DataCallback, opts ...OpOption) BinaryOp
+	Concat(other Operator, opts ...OpOption) BinaryOp
+	Branch(f FilterCallback, opts ...OpOption) BranchOp
+	Inspect(f DataCallback, opts ...OpOption) InspectOp
+	Filter(f FilterCallback, opts ...OpOption) FilterOp
+	Loop(dataF func(ups Operator) Operator, filterF FilterCallback, opts ...LoopOption) EgressOp
+	Iterate(f IterateCallback, opts ...LoopOption) EgressOp
+	Exchange(key KeyCallback, opts ...OpOption) ExchangeOp
+	SortBy(less LessCallback, opts ...OpOption) SortOp
+	TopK(k int, less LessCallback, key KeyCallback, opts ...OpOption) TopKOp
+	Probe(probe *LatencyProbe, opts ...OpOption) ProbeOp
 }
 
 type SingleInput interface {
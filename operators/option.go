@@ -0,0 +1,60 @@
+package operators
+
+// opConfig holds the options of an operator.
+type opConfig struct {
+	// name labels the vertex in errors, logs, metrics and graph exports.
+	name string
+	// probe records the time an input opens each epoch, only used by inputs.
+	probe *LatencyProbe
+}
+
+// OpOption configures an operator built by one of the operator constructors.
+type OpOption interface {
+	applyOp(c *opConfig)
+}
+
+// NameOption gives a name to an operator. Given to Loop or Iterate,
+// it names the scope of the loop instead.
+type NameOption string
+
+// WithName names the operator, so it can be told apart from other operators
+// of the same type in errors, logs, metrics and graph exports.
+func WithName(name string) NameOption {
+	return NameOption(name)
+}
+
+func (o NameOption) applyOp(c *opConfig) {
+	c.name = string(o)
+}
+
+func (o NameOption) applyLoop(c *loopConfig) {
+	if o != "" {
+		c.name = string(o)
+	}
+}
+
+// probeOption makes an input report into a latency probe.
+type probeOption struct {
+	probe *LatencyProbe
+}
+
+// WithProbe makes an input record the time it opens each epoch into the probe,
+// see LatencyProbe. It has no effect on other operators.
+func WithProbe(probe *LatencyProbe) OpOption {
+	return probeOption{probe: probe}
+}
+
+func (o probeOption) applyOp(c *opConfig) {
+	c.probe = o.probe
+}
+
+func newOpConfig(opts []OpOption) *opConfig {
+	c := &opConfig{
+		name:  "",
+		probe: nil,
+	}
+	for _, opt := range opts {
+		opt.applyOp(c)
+	}
+	return c
+}
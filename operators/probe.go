@@ -0,0 +1,239 @@
+package operators
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+// LatencyProbe measures how long each epoch takes from the moment an input opens it
+// to the moment the frontier passes it at a probe operator. Inputs built with WithProbe
+// record the time they first see each epoch, and probe operators built with Operator.Probe
+// report the latency once a timestamp is complete, into the histogram
+// neko_epoch_latency_seconds of the metrics registry of their scope.
+// A probe is shared by all workers of a process, so an epoch counts as opened by
+// whichever worker sees it first. Probe operators ask to be notified of every epoch
+// opened in their process, so epochs whose messages are all filtered out upstream
+// are reported as well, and of every other timestamp they receive messages with.
+type LatencyProbe struct {
+	name string
+	mu   sync.Mutex
+	// Time each epoch was first opened by an input. Entries are kept for the whole run,
+	// as any number of probe operators may still report the epoch.
+	opened map[int]time.Time
+	// Opened epochs in the order they were opened.
+	order []int
+	// Channels of the probe operators, signaled whenever an epoch is opened.
+	subscribers []chan struct{}
+}
+
+func NewLatencyProbe(name string) *LatencyProbe {
+	return &LatencyProbe{
+		name:   name,
+		opened: make(map[int]time.Time),
+	}
+}
+
+// Name returns the name of the probe, which labels its histograms.
+func (p *LatencyProbe) Name() string {
+	return p.name
+}
+
+// Opened returns the time an input first opened the epoch, and false if none did yet.
+func (p *LatencyProbe) Opened(epoch int) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t, exist := p.opened[epoch]
+	return t, exist
+}
+
+// open records that an input opened the epoch at t, unless one did before.
+// It can be called on a nil probe, which records nothing.
+func (p *LatencyProbe) open(epoch int, t time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exist := p.opened[epoch]; exist {
+		return
+	}
+	p.opened[epoch] = t
+	p.order = append(p.order, epoch)
+	for _, ch := range p.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe returns a channel which gets a signal whenever an epoch is opened.
+// Signals are coalesced, so a probe operator receiving one should check all epochs
+// opened since it last checked, see openedSince.
+func (p *LatencyProbe) subscribe() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	p.subscribers = append(p.subscribers, ch)
+	return ch
+}
+
+// openedSince returns the epochs opened after the first n, in the order they were opened.
+func (p *LatencyProbe) openedSince(n int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int{}, p.order[n:]...)
+}
+
+type ProbeHandle interface {
+	handles.VertexHandle
+}
+
+type ProbeOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+}
+
+type ProbeOpCore struct {
+	*OpCore
+	handle ProbeHandle
+	probe  *LatencyProbe
+	// Signaled whenever the probe opens an epoch.
+	opened <-chan struct{}
+	// Number of opened epochs a notification was requested for.
+	seen int
+	// Timestamps a notification is requested for, keyed by the hash of the timestamp.
+	requested map[string]bool
+	// The histogram is created on first use, like the metrics of OpCore.
+	latencyOnce sync.Once
+	latency     *metrics.Histogram
+}
+
+func (op *ProbeOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case <-op.opened:
+			if err := op.notifyOpened(); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *ProbeOpCore) Schedule() (bool, error) {
+	select {
+	case <-op.opened:
+		return true, op.notifyOpened()
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *ProbeOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+// probeLatency returns the histogram the operator reports latencies into.
+func (op *ProbeOpCore) probeLatency() *metrics.Histogram {
+	op.latencyOnce.Do(func() {
+		op.latency = op.Metrics().Histogram(
+			"neko_epoch_latency_seconds",
+			"Time from an input opening an epoch until the frontier passes a timestamp of the epoch at the probe.",
+			metrics.Labels{
+				"probe":  op.probe.Name(),
+				"worker": strconv.Itoa(op.Index()),
+				"vertex": strconv.Itoa(int(op.id)),
+			},
+			metrics.LatencyBuckets,
+		)
+	})
+	return op.latency
+}
+
+// notifyOpened asks to be notified of the epochs opened since it was last called,
+// at the first timestamp of the epoch in the scope of the probe operator.
+func (op *ProbeOpCore) notifyOpened() error {
+	for _, epoch := range op.probe.openedSince(op.seen) {
+		ts := timestamp.NewTimestampWithParams(epoch, make([]int, op.Depth()+1))
+		if err := op.request(*ts); err != nil {
+			return err
+		}
+		op.seen += 1
+	}
+	return nil
+}
+
+// request asks to be notified once the timestamp is complete, unless it did already.
+func (op *ProbeOpCore) request(ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	if op.requested[key] {
+		return nil
+	}
+	if err := op.NotifyAt(ts); err != nil {
+		return err
+	}
+	op.requested[key] = true
+	return nil
+}
+
+func (op *ProbeOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	// An input opens the epoch before sending any message of it, so the epoch
+	// is requested here first, and the timestamp is not requested twice.
+	err := op.notifyOpened()
+	if err == nil {
+		err = op.request(ts)
+	}
+	if err == nil {
+		err = op.SendBy(edge.NewEdge(op.id, op.target), msg, ts)
+	}
+	return op.coreRetire(e, ts, op.handle, err)
+}
+
+func (op *ProbeOpCore) OnNotify(ts timestamp.Timestamp) error {
+	delete(op.requested, utils.Hash(ts))
+	if opened, exist := op.probe.Opened(ts.Epoch); exist {
+		op.probeLatency().Observe(time.Since(opened).Seconds())
+	}
+	return op.coreNotified(ts, op.handle)
+}
+
+func (op *ProbeOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *ProbeOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return op.coreNotifyAt(ts, op.handle)
+}
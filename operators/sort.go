@@ -0,0 +1,130 @@
+package operators
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+type SortHandle interface {
+	handles.VertexHandle
+}
+
+type SortOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+}
+
+// tsBuffer holds the messages received for one timestamp.
+type tsBuffer struct {
+	ts   timestamp.Timestamp
+	msgs []*request.Message
+}
+
+type SortOpCore struct {
+	*OpCore
+	handle SortHandle
+	less   LessCallback
+	// Messages buffered per timestamp, keyed by the hash of the timestamp.
+	buffers map[string]*tsBuffer
+}
+
+func (op *SortOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *SortOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *SortOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *SortOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	if !exist {
+		// First message of this timestamp, so ask to be notified
+		// when all messages of the timestamp have arrived.
+		// The buffer is only kept once the notification is requested,
+		// so the next message of the timestamp tries again otherwise.
+		if err := op.NotifyAt(ts); err != nil {
+			return op.coreRetire(e, ts, op.handle, err)
+		}
+		buf = &tsBuffer{
+			ts:   ts,
+			msgs: []*request.Message{},
+		}
+		op.buffers[key] = buf
+	}
+	buf.msgs = append(buf.msgs, msg)
+	return op.coreRetire(e, ts, op.handle, nil)
+}
+
+func (op *SortOpCore) OnNotify(ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	var err error
+	if exist {
+		delete(op.buffers, key)
+		sort.SliceStable(buf.msgs, func(i, j int) bool {
+			return op.less(buf.msgs[i], buf.msgs[j])
+		})
+		err = op.coreSendIter(
+			iterator.IterFromArray(buf.msgs),
+			edge.NewEdge(op.id, op.target),
+			ts,
+			op.handle,
+		)
+	}
+	if notifiedErr := op.coreNotified(ts, op.handle); notifiedErr != nil {
+		return notifiedErr
+	}
+	return err
+}
+
+func (op *SortOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *SortOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return op.coreNotifyAt(ts, op.handle)
+}
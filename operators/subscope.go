@@ -0,0 +1,162 @@
+package operators
+
+import (
+	"fmt"
+
+	"github.com/stepneko/neko-dataflow/constants"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// SubScope is a loop scope nested in a parent scope.
+// Streams get into the scope by Enter, which appends an iteration counter
+// to timestamps, and get out of it by Leave, which pops the counter again.
+// Feedback creates the back edge of the loop, which increments the counter.
+//
+//	parent -> Enter -> ... body ... -> Leave -> parent
+//	                     ^       |
+//	                     +-Feedback
+//
+// Operators built on streams inside the scope register their vertices
+// through the sub scope, so the scope knows all vertices belonging to it.
+type SubScope struct {
+	scope.Scope
+	name     string
+	label    string
+	depth    int
+	vertices map[vertex.Id]vertex.Vertex
+	// The first vertex registered in the scope, which tells scopes with the same label apart.
+	first vertex.Id
+}
+
+// NewSubScope creates a loop scope nested in the parent scope.
+func NewSubScope(parent scope.Scope, name string) *SubScope {
+	return &SubScope{
+		Scope:    parent,
+		name:     fmt.Sprintf("%s/%s", parent.Name(), name),
+		label:    name,
+		depth:    parent.Depth() + 1,
+		vertices: make(map[vertex.Id]vertex.Vertex),
+	}
+}
+
+// ===================== Impl Scope interface ================== //
+
+func (ss *SubScope) Name() string {
+	return ss.name
+}
+
+func (ss *SubScope) Depth() int {
+	return ss.depth
+}
+
+func (ss *SubScope) RegisterVertex(v vertex.Vertex, handle handles.VertexHandle) error {
+	if ss.first == vertex.Id_Nil {
+		ss.first = v.Id()
+	}
+	if err := ss.Scope.RegisterVertex(v, handle); err != nil {
+		return err
+	}
+	ss.vertices[v.Id()] = v
+	return nil
+}
+
+// ===================== Sub scope functions ================== //
+
+// Parent returns the scope the sub scope is nested in.
+func (ss *SubScope) Parent() scope.Scope {
+	return ss.Scope
+}
+
+// Label returns the label of the scope followed by the id of its first vertex,
+// such as loop2 for a loop entered by vertex 2.
+func (ss *SubScope) Label() string {
+	return fmt.Sprintf("%s%d", ss.label, ss.first)
+}
+
+// Contains tells if the vertex is registered in this sub scope.
+func (ss *SubScope) Contains(vid vertex.Id) bool {
+	_, exist := ss.vertices[vid]
+	return exist
+}
+
+// Iteration returns the iteration counter of this scope from a timestamp
+// of a message inside the scope.
+func (ss *SubScope) Iteration(ts timestamp.Timestamp) (int, error) {
+	if len(ts.Counters) <= ss.depth {
+		return 0, fmt.Errorf("timestamp %s is not inside scope %s", ts.ToString(), ss.name)
+	}
+	return ts.Counters[ss.depth], nil
+}
+
+// Enter brings a stream of the parent scope into this scope.
+func (ss *SubScope) Enter(op Operator) IngressOp {
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := ss.GenerateVID()
+
+	v := &IngressOpCore{
+		OpCore: NewOpCore(vid, vertex.Type_Ingress, ss),
+		handle: handle,
+	}
+
+	ss.RegisterVertex(v, handle)
+	ss.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// Leave brings a stream of this scope out to the parent scope.
+// The egress vertex belongs to the sub scope, while operators
+// built on the returned stream belong to the parent scope.
+func (ss *SubScope) Leave(op Operator) EgressOp {
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := ss.GenerateVID()
+
+	v := &EgressOpCore{
+		OpCore: NewOpCore(vid, vertex.Type_Egress, ss.Parent()),
+		handle: handle,
+	}
+
+	ss.RegisterVertex(v, handle)
+	ss.RegisterEdge(op, v, handle)
+	op.SetTarget(vid)
+
+	return v
+}
+
+// Feedback creates the back edge of the loop. The returned operator is
+// the stream of the next iteration, and a stream of the body is connected
+// to it by FeedbackOp.Connect.
+func (ss *SubScope) Feedback() FeedbackOp {
+	return ss.newFeedback()
+}
+
+func (ss *SubScope) newFeedback() *FeedbackOpCore {
+	taskCh := make(chan request.Request, constants.ChanCapacity)
+	ackCh := make(chan request.Request, constants.ChanCapacity)
+
+	handle := handles.NewLocalVertexHandle(taskCh, ackCh)
+
+	vid := ss.GenerateVID()
+
+	v := &FeedbackOpCore{
+		OpCore: NewOpCore(vid, vertex.Type_Feedback, ss),
+		handle: handle,
+	}
+
+	ss.RegisterVertex(v, handle)
+
+	return v
+}
@@ -0,0 +1,216 @@
+package operators
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/utils"
+)
+
+type TopKHandle interface {
+	handles.VertexHandle
+}
+
+type TopKOp interface {
+	scope.Scope
+	Operator
+	SingleInput
+}
+
+// boundedHeap keeps the first k messages ordered by less.
+// The root is the last of them, so it is the one to evict
+// when a message ordered before it arrives.
+type boundedHeap struct {
+	k    int
+	less LessCallback
+	msgs []*request.Message
+}
+
+func (h *boundedHeap) Len() int {
+	return len(h.msgs)
+}
+
+func (h *boundedHeap) Less(i, j int) bool {
+	return h.less(h.msgs[j], h.msgs[i])
+}
+
+func (h *boundedHeap) Swap(i, j int) {
+	h.msgs[i], h.msgs[j] = h.msgs[j], h.msgs[i]
+}
+
+func (h *boundedHeap) Push(x any) {
+	h.msgs = append(h.msgs, x.(*request.Message))
+}
+
+func (h *boundedHeap) Pop() any {
+	l := len(h.msgs)
+	m := h.msgs[l-1]
+	h.msgs = h.msgs[:l-1]
+	return m
+}
+
+func (h *boundedHeap) offer(msg *request.Message) {
+	if h.k <= 0 {
+		return
+	}
+	if len(h.msgs) < h.k {
+		heap.Push(h, msg)
+		return
+	}
+	if h.less(msg, h.msgs[0]) {
+		h.msgs[0] = msg
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted returns the kept messages ordered by less.
+func (h *boundedHeap) sorted() []*request.Message {
+	res := make([]*request.Message, len(h.msgs))
+	copy(res, h.msgs)
+	sort.SliceStable(res, func(i, j int) bool {
+		return h.less(res[i], res[j])
+	})
+	return res
+}
+
+// topKBuffer holds the bounded heaps of one timestamp, one per key.
+type topKBuffer struct {
+	ts    timestamp.Timestamp
+	heaps map[string]*boundedHeap
+}
+
+type TopKOpCore struct {
+	*OpCore
+	handle TopKHandle
+	k      int
+	less   LessCallback
+	key    KeyCallback
+	// Heaps buffered per timestamp, keyed by the hash of the timestamp.
+	buffers map[string]*topKBuffer
+}
+
+func (op *TopKOpCore) Start(wg *sync.WaitGroup) error {
+	defer wg.Done()
+	for {
+		select {
+		case <-op.Done():
+			return nil
+		case req := <-op.handle.MsgRecv():
+			if err := op.coreHandle(&req, func() error { return op.handleReq(&req) }); err != nil {
+				op.coreLogger().Error(err.Error())
+			}
+		}
+	}
+}
+
+func (op *TopKOpCore) Schedule() (bool, error) {
+	select {
+	case req := <-op.handle.MsgRecv():
+		return true, op.coreHandle(&req, func() error { return op.handleReq(&req) })
+	default:
+		return false, nil
+	}
+}
+
+func (op *TopKOpCore) handleReq(req *request.Request) error {
+	typ := req.Type
+	edge := req.Edge
+	msg := req.Msg
+	ts := req.Ts
+
+	op.tsUpdate(&ts)
+
+	if typ == request.Type_OnRecv {
+		return op.OnRecv(edge, &msg, ts)
+	} else if typ == request.Type_OnNotify {
+		return op.OnNotify(ts)
+	} else {
+		return fmt.Errorf("invalid request type with value: %d", typ)
+	}
+}
+
+func (op *TopKOpCore) OnRecv(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	group := ""
+	if op.key != nil {
+		k, err := op.key(msg)
+		if err != nil {
+			return op.coreRetire(e, ts, op.handle, err)
+		}
+		group = k
+	}
+
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	if !exist {
+		// First message of this timestamp, so ask to be notified
+		// when all messages of the timestamp have arrived.
+		// The buffer is only kept once the notification is requested,
+		// so the next message of the timestamp tries again otherwise.
+		if err := op.NotifyAt(ts); err != nil {
+			return op.coreRetire(e, ts, op.handle, err)
+		}
+		buf = &topKBuffer{
+			ts:    ts,
+			heaps: make(map[string]*boundedHeap),
+		}
+		op.buffers[key] = buf
+	}
+	h, exist := buf.heaps[group]
+	if !exist {
+		h = &boundedHeap{
+			k:    op.k,
+			less: op.less,
+			msgs: []*request.Message{},
+		}
+		buf.heaps[group] = h
+	}
+	h.offer(msg)
+	return op.coreRetire(e, ts, op.handle, nil)
+}
+
+// OnNotify emits the kept messages of the timestamp.
+// Groups are emitted in the order of their keys, and messages
+// within a group are emitted ordered by less.
+func (op *TopKOpCore) OnNotify(ts timestamp.Timestamp) error {
+	key := utils.Hash(ts)
+	buf, exist := op.buffers[key]
+	var err error
+	if exist {
+		delete(op.buffers, key)
+		groups := make([]string, 0, len(buf.heaps))
+		for group := range buf.heaps {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		msgs := []*request.Message{}
+		for _, group := range groups {
+			msgs = append(msgs, buf.heaps[group].sorted()...)
+		}
+		err = op.coreSendIter(
+			iterator.IterFromArray(msgs),
+			edge.NewEdge(op.id, op.target),
+			ts,
+			op.handle,
+		)
+	}
+	if notifiedErr := op.coreNotified(ts, op.handle); notifiedErr != nil {
+		return notifiedErr
+	}
+	return err
+}
+
+func (op *TopKOpCore) SendBy(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) error {
+	return op.coreSendBy(e, msg, ts, op.handle)
+}
+
+func (op *TopKOpCore) NotifyAt(ts timestamp.Timestamp) error {
+	return op.coreNotifyAt(ts, op.handle)
+}
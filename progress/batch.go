@@ -0,0 +1,115 @@
+package progress
+
+import (
+	"fmt"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/request/pb"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"google.golang.org/protobuf/proto"
+)
+
+type Kind int
+
+const (
+	Kind_Updates   Kind = iota // Progress updates
+	Kind_Heartbeat             // Shows the sender is alive, without updates
+	Kind_Abort                 // The sender stops the computation
+)
+
+// Batch is a batch of progress updates broadcast by a process.
+type Batch struct {
+	Kind Kind
+	// Index of the process sending the batch.
+	Sender int
+	// Batches from a sender are numbered from 1 without gaps,
+	// so receivers can check that none is lost or reordered.
+	Seq     uint64
+	Updates []graph.Update
+	// Why the sender aborts, for abort batches.
+	Reason string
+}
+
+func (b *Batch) ToProto() *pb.ProgressBatch {
+	res := &pb.ProgressBatch{
+		Version: request.WireVersion,
+		Sender:  uint32(b.Sender),
+		Seq:     b.Seq,
+		Updates: make([]*pb.ProgressUpdate, len(b.Updates)),
+		Kind:    pb.ProgressKind(b.Kind),
+		Reason:  b.Reason,
+	}
+	for i, u := range b.Updates {
+		ts := u.Ps.GetTimestamp()
+		counters := make([]int64, len(ts.Counters))
+		for j, c := range ts.Counters {
+			counters[j] = int64(c)
+		}
+		res.Updates[i] = &pb.ProgressUpdate{
+			Edge: &pb.Edge{
+				Src:    int64(u.Ps.GetSrc()),
+				Target: int64(u.Ps.GetTarget()),
+			},
+			Ts: &pb.Timestamp{
+				Epoch:    int64(ts.Epoch),
+				Counters: counters,
+			},
+			Delta: int64(u.Delta),
+		}
+	}
+	return res
+}
+
+func BatchFromProto(p *pb.ProgressBatch) (*Batch, error) {
+	if p.GetVersion() != request.WireVersion {
+		return nil, fmt.Errorf("unsupported wire version %d, expect %d", p.GetVersion(), request.WireVersion)
+	}
+	if _, exist := pb.ProgressKind_name[int32(p.GetKind())]; !exist {
+		return nil, fmt.Errorf("invalid progress batch kind with value: %d", p.GetKind())
+	}
+	b := &Batch{
+		Kind:    Kind(p.GetKind()),
+		Sender:  int(p.GetSender()),
+		Seq:     p.GetSeq(),
+		Updates: make([]graph.Update, len(p.GetUpdates())),
+		Reason:  p.GetReason(),
+	}
+	for i, u := range p.GetUpdates() {
+		if u.GetEdge() == nil {
+			return nil, fmt.Errorf("progress update without location in batch %d", p.GetSeq())
+		}
+		counters := make([]int, len(u.GetTs().GetCounters()))
+		for j, c := range u.GetTs().GetCounters() {
+			counters[j] = int(c)
+		}
+		ts := timestamp.NewTimestampWithParams(int(u.GetTs().GetEpoch()), counters)
+		src := vertex.Id(u.GetEdge().GetSrc())
+		target := vertex.Id(u.GetEdge().GetTarget())
+		var ps graph.Pointstamp
+		if src == target {
+			ps = graph.NewVertexPointStamp(src, ts)
+		} else {
+			ps = graph.NewEdgePointStamp(edge.NewEdge(src, target), ts)
+		}
+		b.Updates[i] = graph.Update{
+			Ps:    ps,
+			Delta: int(u.GetDelta()),
+		}
+	}
+	return b, nil
+}
+
+func (b *Batch) Marshal() ([]byte, error) {
+	return proto.Marshal(b.ToProto())
+}
+
+func UnmarshalBatch(buf []byte) (*Batch, error) {
+	p := &pb.ProgressBatch{}
+	if err := proto.Unmarshal(buf, p); err != nil {
+		return nil, fmt.Errorf("cannot decode progress batch: %w", err)
+	}
+	return BatchFromProto(p)
+}
@@ -0,0 +1,369 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/graph"
+)
+
+// Config controls how progress updates are batched.
+type Config struct {
+	// Pending updates are broadcast at least this often.
+	FlushInterval time.Duration
+	// Pending updates are broadcast as soon as this many pointstamps changed.
+	MaxBatch int
+	// Heartbeats are sent to all peers this often. Zero disables heartbeats.
+	HeartbeatInterval time.Duration
+	// A peer is considered failed if nothing is received from it for this long.
+	// Zero disables the failure detector, which otherwise needs heartbeats
+	// more often than this.
+	FailureTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		FlushInterval:     time.Millisecond,
+		MaxBatch:          1024,
+		HeartbeatInterval: 100 * time.Millisecond,
+		FailureTimeout:    3 * time.Second,
+	}
+}
+
+// PeerFailedError tells that a peer process is unreachable.
+type PeerFailedError struct {
+	Process int
+	Err     error
+}
+
+func (e *PeerFailedError) Error() string {
+	return fmt.Sprintf("peer process %d failed: %v", e.Process, e.Err)
+}
+
+func (e *PeerFailedError) Unwrap() error {
+	return e.Err
+}
+
+// AbortError tells that a peer process stopped the computation.
+type AbortError struct {
+	Process int
+	Reason  string
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("peer process %d aborted: %s", e.Process, e.Reason)
+}
+
+// pendingCount accumulates the updates of a pointstamp until the next broadcast.
+// Increments and decrements are kept apart since increments are already applied
+// to the local tracker while decrements are not.
+type pendingCount struct {
+	ps    graph.Pointstamp
+	incre int
+	decre int
+}
+
+// Exchanger implements the distributed progress tracking of Naiad.
+// Each process keeps a local view of the occurrence counts of all pointstamps
+// in its tracker. Updates made by local workers are accumulated and broadcast
+// in batches to all peers, which apply every batch atomically.
+//
+// No notification is delivered early, because:
+//   - Increments are applied to the local view at once, and broadcast with or before
+//     the decrements they result from, as a batch contains all updates made before it.
+//   - Decrements are applied to the local view only after they are sent to all peers.
+//   - Batches from a peer are applied in order, and a pointstamp with a negative count
+//     is still active, so a decrement arriving before its increment from another peer
+//     still holds the frontier back.
+type Exchanger struct {
+	mu sync.Mutex
+	// Held while a batch is taken from the pending updates and sent, so batches
+	// leave in order without holding mu across sends to slow peers.
+	sendMu  sync.Mutex
+	index   int
+	config  Config
+	tracker *graph.Tracker
+	peers   []Peer
+	pending map[string]*pendingCount
+	// Hashes of pending pointstamps in the order they are first updated.
+	order []string
+	seq   uint64
+	// Last sequence number received from each sender.
+	recvSeq map[int]uint64
+	// Last time anything was received from each peer process.
+	lastSeen map[int]time.Time
+	errCh    chan error
+	aborted  bool
+}
+
+// NewExchanger creates the exchanger of process index with its peers,
+// and attaches it to the tracker of the process.
+func NewExchanger(
+	index int,
+	tracker *graph.Tracker,
+	peers []Peer,
+	config Config,
+) *Exchanger {
+	x := &Exchanger{
+		index:    index,
+		config:   config,
+		tracker:  tracker,
+		peers:    peers,
+		pending:  make(map[string]*pendingCount),
+		order:    []string{},
+		recvSeq:  make(map[int]uint64),
+		lastSeen: make(map[int]time.Time),
+		errCh:    make(chan error, 1),
+	}
+	tracker.SetExchange(x)
+	return x
+}
+
+// Record buffers an update made by a local worker.
+func (x *Exchanger) Record(ps graph.Pointstamp, delta int) {
+	x.mu.Lock()
+	psHash := ps.Hash()
+	p, exist := x.pending[psHash]
+	if !exist {
+		p = &pendingCount{ps: ps}
+		x.pending[psHash] = p
+		x.order = append(x.order, psHash)
+	}
+	if delta > 0 {
+		p.incre += delta
+	} else {
+		p.decre += delta
+	}
+	full := len(x.order) >= x.config.MaxBatch
+	x.mu.Unlock()
+	if full {
+		if err := x.Flush(); err != nil {
+			x.fail(err)
+		}
+	}
+}
+
+// Flush broadcasts all pending updates.
+func (x *Exchanger) Flush() error {
+	x.sendMu.Lock()
+	defer x.sendMu.Unlock()
+
+	x.mu.Lock()
+	batch, decres := x.takeBatch()
+	x.mu.Unlock()
+
+	if batch == nil && len(decres) == 0 {
+		return nil
+	}
+	if batch != nil {
+		for _, p := range x.peers {
+			if err := p.Send(batch); err != nil {
+				return &PeerFailedError{Process: p.Process(), Err: err}
+			}
+		}
+	}
+
+	// All peers have the decrements now, so the local view may move forward.
+	return x.tracker.Apply(decres)
+}
+
+// Run receives batches from all peers and flushes pending updates periodically,
+// until the context is done or an error occurs. If a peer fails or aborts, or Abort is called,
+// Run tells all other peers to abort and returns the error.
+func (x *Exchanger) Run(ctx context.Context) error {
+	now := time.Now()
+	x.mu.Lock()
+	for _, p := range x.peers {
+		x.lastSeen[p.Process()] = now
+	}
+	x.mu.Unlock()
+	for _, p := range x.peers {
+		go x.recvLoop(ctx, p)
+	}
+
+	flushTicker := time.NewTicker(x.config.FlushInterval)
+	defer flushTicker.Stop()
+	var heartbeat <-chan time.Time
+	if x.config.HeartbeatInterval > 0 {
+		heartbeatTicker := time.NewTicker(x.config.HeartbeatInterval)
+		defer heartbeatTicker.Stop()
+		heartbeat = heartbeatTicker.C
+	}
+	var check <-chan time.Time
+	if x.config.FailureTimeout > 0 {
+		checkTicker := time.NewTicker(x.config.FailureTimeout / 4)
+		defer checkTicker.Stop()
+		check = checkTicker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case err = <-x.errCh:
+		case <-flushTicker.C:
+			err = x.Flush()
+		case <-heartbeat:
+			err = x.heartbeat()
+		case <-check:
+			err = x.detectFailure()
+		}
+		if err != nil {
+			x.broadcastAbort(err)
+			return err
+		}
+	}
+}
+
+// Abort tells all peers to abort because of a local error, and makes Run return err.
+func (x *Exchanger) Abort(err error) {
+	x.broadcastAbort(err)
+	x.fail(err)
+}
+
+// takeBatch empties the pending updates into the next batch to broadcast, if any
+// pointstamp changed, and the decrements to apply locally once it is sent.
+func (x *Exchanger) takeBatch() (*Batch, []graph.Update) {
+	batch := &Batch{
+		Sender:  x.index,
+		Updates: []graph.Update{},
+	}
+	decres := []graph.Update{}
+	for _, psHash := range x.order {
+		p := x.pending[psHash]
+		if delta := p.incre + p.decre; delta != 0 {
+			batch.Updates = append(batch.Updates, graph.Update{Ps: p.ps, Delta: delta})
+		}
+		if p.decre != 0 {
+			decres = append(decres, graph.Update{Ps: p.ps, Delta: p.decre})
+		}
+	}
+	x.pending = make(map[string]*pendingCount)
+	x.order = []string{}
+
+	if len(batch.Updates) == 0 {
+		return nil, decres
+	}
+	x.seq += 1
+	batch.Seq = x.seq
+	return batch, decres
+}
+
+func (x *Exchanger) recvLoop(ctx context.Context, p Peer) {
+	for {
+		b, err := p.Recv()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			default:
+				x.fail(&PeerFailedError{Process: p.Process(), Err: err})
+			}
+			return
+		}
+		if err := x.receive(p, b); err != nil {
+			x.fail(err)
+			return
+		}
+	}
+}
+
+func (x *Exchanger) receive(p Peer, b *Batch) error {
+	x.mu.Lock()
+	x.lastSeen[p.Process()] = time.Now()
+	if b.Sender != p.Process() {
+		x.mu.Unlock()
+		return fmt.Errorf("progress batch from process %d received from process %d", b.Sender, p.Process())
+	}
+	if b.Kind == Kind_Heartbeat {
+		x.mu.Unlock()
+		return nil
+	}
+	if b.Kind == Kind_Abort {
+		// The peer already told everyone, so do not echo its abort.
+		x.aborted = true
+		x.mu.Unlock()
+		return &AbortError{Process: b.Sender, Reason: b.Reason}
+	}
+	last := x.recvSeq[b.Sender]
+	if b.Seq != last+1 {
+		x.mu.Unlock()
+		return fmt.Errorf("progress batch %d from process %d out of order, expect %d", b.Seq, b.Sender, last+1)
+	}
+	x.recvSeq[b.Sender] = b.Seq
+	x.mu.Unlock()
+	return x.tracker.Apply(b.Updates)
+}
+
+func (x *Exchanger) heartbeat() error {
+	b := &Batch{
+		Kind:    Kind_Heartbeat,
+		Sender:  x.index,
+		Updates: []graph.Update{},
+	}
+	for _, p := range x.peers {
+		if err := p.Send(b); err != nil {
+			return &PeerFailedError{Process: p.Process(), Err: err}
+		}
+	}
+	return nil
+}
+
+// detectFailure returns an error for the first peer not heard from within the failure timeout.
+func (x *Exchanger) detectFailure() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	now := time.Now()
+	for _, p := range x.peers {
+		if d := now.Sub(x.lastSeen[p.Process()]); d > x.config.FailureTimeout {
+			return &PeerFailedError{
+				Process: p.Process(),
+				Err:     fmt.Errorf("no heartbeat for %v", d.Round(time.Millisecond)),
+			}
+		}
+	}
+	return nil
+}
+
+// broadcastAbort tells all reachable peers to abort. Errors are ignored,
+// as peers which cannot be reached detect the failure by themselves.
+// Peers are told in parallel, so slow peers only delay the abort once.
+func (x *Exchanger) broadcastAbort(err error) {
+	x.mu.Lock()
+	if x.aborted {
+		x.mu.Unlock()
+		return
+	}
+	x.aborted = true
+	x.mu.Unlock()
+
+	b := &Batch{
+		Kind:    Kind_Abort,
+		Sender:  x.index,
+		Updates: []graph.Update{},
+		Reason:  err.Error(),
+	}
+	var wg sync.WaitGroup
+	for _, p := range x.peers {
+		var failed *PeerFailedError
+		if errors.As(err, &failed) && failed.Process == p.Process() {
+			continue
+		}
+		wg.Add(1)
+		go func(p Peer) {
+			defer wg.Done()
+			p.Send(b)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (x *Exchanger) fail(err error) {
+	select {
+	case x.errCh <- err:
+	default:
+	}
+}
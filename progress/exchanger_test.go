@@ -0,0 +1,280 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTracker builds the tracker of one process for the dataflow
+// [v1]Input -> [v2]Inspect, run by one worker in each of two processes.
+func newTracker() *graph.Tracker {
+	tracker := graph.NewTracker(2)
+	tracker.InsertVertex(1, vertex.Type_Input)
+	tracker.InsertVertex(2, vertex.Type_Inspect)
+	tracker.InsertEdge(edge.NewEdge(1, 2))
+	tracker.PreProcess()
+	return tracker
+}
+
+// manualConfig only broadcasts updates on Flush, without failure detection.
+func manualConfig() Config {
+	return Config{
+		FlushInterval: time.Hour,
+		MaxBatch:      1024,
+	}
+}
+
+// detectorConfig detects failures quickly.
+func detectorConfig() Config {
+	return Config{
+		FlushInterval:     time.Millisecond,
+		MaxBatch:          1024,
+		HeartbeatInterval: 10 * time.Millisecond,
+		FailureTimeout:    200 * time.Millisecond,
+	}
+}
+
+// blockingPeer blocks every send until release is closed,
+// like a peer which stopped reading.
+type blockingPeer struct {
+	process int
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPeer) Process() int {
+	return p.process
+}
+
+func (p *blockingPeer) Send(b *Batch) error {
+	p.sending <- struct{}{}
+	<-p.release
+	return nil
+}
+
+func (p *blockingPeer) Recv() (*Batch, error) {
+	<-p.release
+	return nil, errors.New("closed")
+}
+
+// tcpPair returns both ends of a TCP connection on localhost.
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := l.Accept()
+		assert.Nil(t, err)
+		accepted <- conn
+	}()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	return conn, <-accepted
+}
+
+func TestBatchRoundTrip(t *testing.T) {
+	ts := timestamp.NewTimestampWithParams(1, []int{0, 2})
+	b := &Batch{
+		Sender: 3,
+		Seq:    7,
+		Updates: []graph.Update{
+			{Ps: graph.NewVertexPointStamp(1, ts), Delta: -1},
+			{Ps: graph.NewEdgePointStamp(edge.NewEdge(1, 2), ts), Delta: 2},
+		},
+	}
+	buf, err := b.Marshal()
+	assert.Nil(t, err)
+	res, err := UnmarshalBatch(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, res.Sender)
+	assert.Equal(t, uint64(7), res.Seq)
+	assert.Equal(t, 2, len(res.Updates))
+	for i := range b.Updates {
+		assert.Equal(t, b.Updates[i].Ps.Hash(), res.Updates[i].Ps.Hash())
+		assert.Equal(t, b.Updates[i].Delta, res.Updates[i].Delta)
+	}
+}
+
+func TestExchangerNoEarlyNotification(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	connA, connB := net.Pipe()
+	trackerA := newTracker()
+	trackerB := newTracker()
+	xA := NewExchanger(0, trackerA, []Peer{NewConnPeer(1, connA)}, manualConfig())
+	xB := NewExchanger(1, trackerB, []Peer{NewConnPeer(0, connB)}, manualConfig())
+	go xA.Run(ctx)
+	go xB.Run(ctx)
+
+	ts := timestamp.NewTimestamp()
+	input := graph.NewVertexPointStamp(1, ts)
+	msg := graph.NewEdgePointStamp(edge.NewEdge(1, 2), ts)
+	notification := graph.NewVertexPointStamp(2, ts)
+
+	inFrontier := func(tracker *graph.Tracker) bool {
+		res, err := tracker.InFrontier(notification)
+		assert.Nil(t, err)
+		return res
+	}
+
+	// Process B asks for a notification and closes its input.
+	assert.Nil(t, trackerB.IncreOC(notification))
+	assert.Nil(t, trackerB.DecreOC(input))
+	assert.False(t, inFrontier(trackerB))
+	assert.Nil(t, xB.Flush())
+
+	// Process A sends a message and closes its input, but has not broadcast yet.
+	assert.Nil(t, trackerA.IncreOC(msg))
+	assert.Nil(t, trackerA.DecreOC(input))
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, inFrontier(trackerB))
+
+	// Now B knows about the message in flight.
+	assert.Nil(t, xA.Flush())
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, inFrontier(trackerB))
+
+	// The message is processed, and the notification can be delivered.
+	assert.Nil(t, trackerA.DecreOC(msg))
+	assert.Nil(t, xA.Flush())
+	assert.Eventually(t, func() bool { return inFrontier(trackerB) }, time.Second, time.Millisecond)
+}
+
+func TestExchangerOutOfOrderBatch(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	connA, connB := tcpPair(t)
+	peerA := NewConnPeer(1, connA)
+	x := NewExchanger(1, newTracker(), []Peer{NewConnPeer(0, connB)}, manualConfig())
+	errCh := make(chan error)
+	go func() {
+		errCh <- x.Run(ctx)
+	}()
+
+	assert.Nil(t, peerA.Send(&Batch{Sender: 0, Seq: 2, Updates: []graph.Update{}}))
+	assert.Error(t, <-errCh)
+}
+
+func TestExchangerHeartbeat(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	connA, connB := tcpPair(t)
+	xA := NewExchanger(0, newTracker(), []Peer{NewConnPeer(1, connA)}, detectorConfig())
+	xB := NewExchanger(1, newTracker(), []Peer{NewConnPeer(0, connB)}, detectorConfig())
+	errCh := make(chan error, 2)
+	go func() { errCh <- xA.Run(ctx) }()
+	go func() { errCh <- xB.Run(ctx) }()
+
+	// Idle peers keep each other alive with heartbeats.
+	select {
+	case err := <-errCh:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestExchangerFailureDetector(t *testing.T) {
+	connA, connB := tcpPair(t)
+	defer connB.Close()
+	x := NewExchanger(0, newTracker(), []Peer{NewConnPeer(1, connA)}, detectorConfig())
+
+	// The peer never sends anything.
+	err := x.Run(context.Background())
+	var failed *PeerFailedError
+	assert.True(t, errors.As(err, &failed))
+	assert.Equal(t, 1, failed.Process)
+	assert.Contains(t, err.Error(), "peer process 1 failed")
+}
+
+func TestExchangerPeerClosed(t *testing.T) {
+	connA, connB := tcpPair(t)
+	x := NewExchanger(0, newTracker(), []Peer{NewConnPeer(1, connA)}, manualConfig())
+	connB.Close()
+
+	err := x.Run(context.Background())
+	var failed *PeerFailedError
+	assert.True(t, errors.As(err, &failed))
+	assert.Equal(t, 1, failed.Process)
+}
+
+func TestExchangerAbort(t *testing.T) {
+	connA, connB := tcpPair(t)
+	xA := NewExchanger(0, newTracker(), []Peer{NewConnPeer(1, connA)}, detectorConfig())
+	xB := NewExchanger(1, newTracker(), []Peer{NewConnPeer(0, connB)}, detectorConfig())
+	errCh := make(chan error, 1)
+	go func() { errCh <- xB.Run(context.Background()) }()
+
+	boom := errors.New("boom")
+	xA.Abort(boom)
+	assert.Equal(t, boom, xA.Run(context.Background()))
+
+	err := <-errCh
+	var aborted *AbortError
+	assert.True(t, errors.As(err, &aborted))
+	assert.Equal(t, 0, aborted.Process)
+	assert.Equal(t, "boom", aborted.Reason)
+}
+
+func TestExchangerSendWithoutLock(t *testing.T) {
+	sending := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	tracker := newTracker()
+	x := NewExchanger(0, tracker, []Peer{&blockingPeer{1, sending, release}}, manualConfig())
+
+	ts := timestamp.NewTimestamp()
+	assert.Nil(t, tracker.IncreOC(graph.NewVertexPointStamp(2, ts)))
+	go x.Flush()
+	<-sending
+
+	// Local workers keep recording updates while the batch is stuck.
+	recorded := make(chan struct{})
+	go func() {
+		assert.Nil(t, tracker.IncreOC(graph.NewEdgePointStamp(edge.NewEdge(1, 2), ts)))
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Fatal("update blocked by a pending send")
+	}
+}
+
+func TestExchangerAbortInParallel(t *testing.T) {
+	sending := make(chan struct{}, 2)
+	release := make(chan struct{})
+	x := NewExchanger(0, newTracker(), []Peer{
+		&blockingPeer{1, sending, release},
+		&blockingPeer{2, sending, release},
+	}, manualConfig())
+
+	aborted := make(chan struct{})
+	go func() {
+		x.Abort(errors.New("boom"))
+		close(aborted)
+	}()
+
+	// Both peers are told at once, not one after the other.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-sending:
+		case <-time.After(time.Second):
+			t.Fatal("abort waits for one peer before telling the next")
+		}
+	}
+	close(release)
+	<-aborted
+}
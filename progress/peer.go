@@ -0,0 +1,63 @@
+package progress
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/request"
+)
+
+// Peer is the link to another process of the cluster
+// over which progress batches are exchanged.
+type Peer interface {
+	// Process returns the index of the process at the other end.
+	Process() int
+	Send(b *Batch) error
+	// Recv blocks until the next batch from the peer arrives.
+	Recv() (*Batch, error)
+}
+
+// A send blocked for this long means the peer stopped reading.
+const sendTimeout = 10 * time.Second
+
+// ConnPeer exchanges progress batches as frames over a connection.
+type ConnPeer struct {
+	process int
+	conn    net.Conn
+	mu      sync.Mutex
+}
+
+func NewConnPeer(process int, conn net.Conn) *ConnPeer {
+	return &ConnPeer{
+		process: process,
+		conn:    conn,
+	}
+}
+
+func (p *ConnPeer) Process() int {
+	return p.process
+}
+
+func (p *ConnPeer) Send(b *Batch) error {
+	buf, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+	return request.WriteFrame(p.conn, buf)
+}
+
+func (p *ConnPeer) Recv() (*Batch, error) {
+	buf, err := request.ReadFrame(p.conn)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBatch(buf)
+}
+
+func (p *ConnPeer) Close() error {
+	return p.conn.Close()
+}
@@ -0,0 +1,133 @@
+package request
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stepneko/neko-dataflow/constants"
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/request/pb"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"google.golang.org/protobuf/proto"
+)
+
+// WireVersion is the version of the encoding of requests.
+// It must be increased on any incompatible change of request/pb/request.proto.
+const WireVersion uint32 = 1
+
+// ToProto converts a request into its wire representation.
+func ToProto(req *Request) *pb.Request {
+	res := &pb.Request{
+		Version: WireVersion,
+		Type:    pb.Type(req.Type),
+		Ts: &pb.Timestamp{
+			Epoch:    int64(req.Ts.Epoch),
+			Counters: make([]int64, len(req.Ts.Counters)),
+		},
+		Msg: &pb.Message{
+			Data: req.Msg.Data(),
+		},
+	}
+	for i, c := range req.Ts.Counters {
+		res.Ts.Counters[i] = int64(c)
+	}
+	if req.Edge != nil {
+		res.Edge = &pb.Edge{
+			Src:    int64(req.Edge.GetSrc()),
+			Target: int64(req.Edge.GetTarget()),
+		}
+	}
+	return res
+}
+
+// FromProto converts the wire representation back into a request.
+func FromProto(p *pb.Request) (*Request, error) {
+	if p.GetVersion() != WireVersion {
+		return nil, fmt.Errorf("unsupported wire version %d, expect %d", p.GetVersion(), WireVersion)
+	}
+	if _, exist := pb.Type_name[int32(p.GetType())]; !exist {
+		return nil, fmt.Errorf("invalid request type with value: %d", p.GetType())
+	}
+	req := &Request{
+		Type: Type(p.GetType()),
+	}
+	if p.Edge != nil {
+		req.Edge = edge.NewEdge(vertex.Id(p.Edge.GetSrc()), vertex.Id(p.Edge.GetTarget()))
+	}
+	counters := make([]int, len(p.GetTs().GetCounters()))
+	for i, c := range p.GetTs().GetCounters() {
+		counters[i] = int(c)
+	}
+	req.Ts = *timestamp.NewTimestampWithParams(int(p.GetTs().GetEpoch()), counters)
+	req.Msg = *NewMessage(p.GetMsg().GetData())
+	return req, nil
+}
+
+// Marshal encodes a request into bytes to be sent over the network or written to disk.
+func Marshal(req *Request) ([]byte, error) {
+	return proto.Marshal(ToProto(req))
+}
+
+// Unmarshal decodes a request encoded by Marshal.
+func Unmarshal(buf []byte) (*Request, error) {
+	p := &pb.Request{}
+	if err := proto.Unmarshal(buf, p); err != nil {
+		return nil, fmt.Errorf("cannot decode request: %w", err)
+	}
+	return FromProto(p)
+}
+
+// WriteRequest encodes a request and writes it as a frame.
+func WriteRequest(w io.Writer, req *Request) error {
+	payload, err := Marshal(req)
+	if err != nil {
+		return err
+	}
+	return WriteFrame(w, payload)
+}
+
+// ReadRequest reads a request written by WriteRequest.
+// It returns io.EOF if the reader is closed between two requests.
+func ReadRequest(r io.Reader) (*Request, error) {
+	payload, err := ReadFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(payload)
+}
+
+// WriteFrame writes the payload prefixed with its length as a 4 bytes big endian integer.
+func WriteFrame(w io.Writer, payload []byte) error {
+	if len(payload) > constants.MaxFrameSize {
+		return fmt.Errorf("frame too large with size %d", len(payload))
+	}
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadFrame reads a payload written by WriteFrame.
+// It returns io.EOF if the reader is closed between two frames.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if int(size) > constants.MaxFrameSize {
+		return nil, fmt.Errorf("frame too large with size %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,99 @@
+package request
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	req := &Request{
+		Type: Type_SendBy,
+		Edge: edge.NewEdge(3, 7),
+		Msg:  *NewMessage([]byte("hello")),
+		Ts:   *timestamp.NewTimestampWithParams(2, []int{0, 5}),
+	}
+	buf, err := Marshal(req)
+	assert.Nil(t, err)
+	res, err := Unmarshal(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, req.Type, res.Type)
+	assert.Equal(t, req.Edge.GetSrc(), res.Edge.GetSrc())
+	assert.Equal(t, req.Edge.GetTarget(), res.Edge.GetTarget())
+	assert.Equal(t, "hello", res.Msg.ToString())
+	assert.Equal(t, req.Ts, res.Ts)
+
+	ack := &Request{Type: Type_Ack}
+	buf, err = Marshal(ack)
+	assert.Nil(t, err)
+	res, err = Unmarshal(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, Type_Ack, res.Type)
+	assert.Nil(t, res.Edge)
+	assert.Equal(t, 0, len(res.Msg.Data()))
+
+	buf, err = Marshal(req)
+	assert.Nil(t, err)
+	_, err = Unmarshal(buf[:5])
+	assert.Error(t, err)
+}
+
+func TestWireVersion(t *testing.T) {
+	p := ToProto(&Request{Type: Type_Ack})
+	assert.Equal(t, WireVersion, p.GetVersion())
+
+	p.Version = WireVersion + 1
+	buf, err := proto.Marshal(p)
+	assert.Nil(t, err)
+	_, err = Unmarshal(buf)
+	assert.Error(t, err)
+
+	// Requests without version come from an unknown encoder
+	_, err = Unmarshal([]byte{})
+	assert.Error(t, err)
+}
+
+func TestReadWriteRequest(t *testing.T) {
+	buf := &bytes.Buffer{}
+	for i := 0; i < 3; i++ {
+		err := WriteRequest(buf, &Request{
+			Type: Type_OnRecv,
+			Edge: edge.NewEdge(1, 2),
+			Msg:  *NewMessage([]byte("m")),
+			Ts:   *timestamp.NewTimestampWithParams(i, []int{0}),
+		})
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 3; i++ {
+		req, err := ReadRequest(buf)
+		assert.Nil(t, err)
+		assert.Equal(t, i, req.Ts.Epoch)
+	}
+	_, err := ReadRequest(buf)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFrame(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteFrame(buf, []byte("abc")))
+	assert.Nil(t, WriteFrame(buf, []byte{}))
+
+	payload, err := ReadFrame(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("abc"), payload)
+	payload, err = ReadFrame(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(payload))
+	_, err = ReadFrame(buf)
+	assert.Equal(t, io.EOF, err)
+
+	assert.Nil(t, WriteFrame(buf, []byte("abc")))
+	buf.Truncate(5)
+	_, err = ReadFrame(buf)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
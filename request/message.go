@@ -17,3 +17,8 @@ func NewMessage(data []byte) *Message {
 func (m *Message) ToString() string {
 	return string(m.data[:])
 }
+
+// Data returns the raw bytes of the message.
+func (m *Message) Data() []byte {
+	return m.data
+}
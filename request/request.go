@@ -1,6 +1,8 @@
 package request
 
 import (
+	"fmt"
+
 	"github.com/stepneko/neko-dataflow/edge"
 	"github.com/stepneko/neko-dataflow/timestamp"
 )
@@ -17,6 +19,23 @@ const (
 	Type_Ack                  //  Function signature to ack
 )
 
+var typeNames = map[Type]string{
+	Type_SendBy:   "SendBy",
+	Type_NotifyAt: "NotifyAt",
+	Type_OnRecv:   "OnRecv",
+	Type_OnNotify: "OnNotify",
+	Type_IncreOC:  "IncreOC",
+	Type_DecreOC:  "DecreOC",
+	Type_Ack:      "Ack",
+}
+
+func (t Type) String() string {
+	if name, exist := typeNames[t]; exist {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
 // Request represents a call between vertices and scheduler.
 type Request struct {
 	Type Type
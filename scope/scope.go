@@ -1,21 +1,68 @@
 package scope
 
 import (
+	"strings"
+
+	"github.com/stepneko/neko-dataflow/events"
 	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/tracing"
 	"github.com/stepneko/neko-dataflow/vertex"
+	"go.uber.org/zap"
 )
 
 type Scope interface {
 	// Name returns the name of the scope
 	Name() string
+	// Depth returns the number of loop scopes this scope is nested in.
+	// Timestamps inside the scope carry Depth() + 1 counters.
+	Depth() int
 	// GenerateVID generates a unique vertex id for new vertex
 	GenerateVID() vertex.Id
 	// GetWorkerHandle gets the handle of the worker
 	GetWorkerHandle() handles.WorkerHandle
+	// Index returns the index of the worker running the scope
+	Index() int
+	// Peers returns the number of workers running the same dataflow
+	Peers() int
+	// GetPeerHandle gets the handle of the worker with the given index
+	GetPeerHandle(index int) (handles.WorkerHandle, error)
 	// RegisterVertex registers a vertex with its handle to the scope
 	RegisterVertex(v vertex.Vertex, handle handles.VertexHandle) error
 	// RegisterEdge registers an edge with its target handle to the scope
 	RegisterEdge(src vertex.Vertex, target vertex.Vertex, handle handles.VertexHandle) error
+	// Metrics returns the registry the vertices of the scope report into, nil if metrics are disabled
+	Metrics() *metrics.Registry
+	// Events returns the log the vertices of the scope emit events to, nil if events are disabled
+	Events() *events.Log
+	// Tracer returns the recorder the vertices of the scope record spans into, nil if tracing is disabled
+	Tracer() *tracing.Recorder
+	// Logger returns the logger of the scope
+	Logger() *zap.Logger
 	// Done indicates that the scope is done with computation
 	Done() <-chan struct{}
 }
+
+// Nested is implemented by scopes nested in another scope, such as the scope of a loop.
+type Nested interface {
+	Scope
+	// Parent returns the scope the scope is nested in
+	Parent() Scope
+	// Label identifies the scope among all scopes nested in the same worker
+	Label() string
+}
+
+// Path returns the labels of the nested scopes from the outermost one down to s,
+// separated by "/". It is empty for the scope of the worker.
+func Path(s Scope) string {
+	labels := []string{}
+	for {
+		n, ok := s.(Nested)
+		if !ok {
+			break
+		}
+		labels = append([]string{n.Label()}, labels...)
+		s = n.Parent()
+	}
+	return strings.Join(labels, "/")
+}
@@ -0,0 +1,102 @@
+package step
+
+import (
+	"context"
+
+	"github.com/stepneko/neko-dataflow/cluster"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/progress"
+	"github.com/stepneko/neko-dataflow/utils"
+	"github.com/stepneko/neko-dataflow/worker"
+)
+
+// ExecuteCluster runs the workers of this process as part of a cluster.
+// Every process of the cluster must call it with the same dataflow, so vertices
+// get the same ids everywhere. The workers are numbered over the whole cluster,
+// see cluster.Config.WorkerIndex, and progress is exchanged between all processes.
+// If a peer process fails or aborts, all processes stop and the returned error names the peer.
+// The workers are built and run as described by workerConfig, like with Execute,
+// except that the number of workers in this process is the one of config.
+func ExecuteCluster(config cluster.Config, workerConfig worker.Config, fn StartFn) error {
+	workerConfig.Workers = config.Workers
+	if err := workerConfig.Validate(); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	c, err := cluster.Connect(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tracker := graph.NewTracker(config.Peers())
+	tracker.SetCheck(workerConfig.CheckProgress)
+	x := progress.NewExchanger(config.Process, tracker, c.ProgressPeers(), config.Progress)
+
+	workers := []*worker.SimpleWorker{}
+	localHandles := []handles.WorkerHandle{}
+	for i := 0; i < config.Workers; i++ {
+		id := worker.Id(config.WorkerIndex(i))
+		w := workerConfig.NewWorker(ctx, id, config.Peers(), tracker)
+		workers = append(workers, w)
+		localHandles = append(localHandles, w.InboxHandle())
+	}
+
+	peerHandles := []handles.WorkerHandle{}
+	for i := 0; i < config.Peers(); i++ {
+		if config.ProcessOf(i) == config.Process {
+			peerHandles = append(peerHandles, localHandles[i-config.WorkerIndex(0)])
+			continue
+		}
+		h, err := c.WorkerHandle(i)
+		if err != nil {
+			return err
+		}
+		peerHandles = append(peerHandles, h)
+	}
+
+	for _, w := range workers {
+		if err := w.ConnectPeers(peerHandles); err != nil {
+			return err
+		}
+		if err := fn(w); err != nil {
+			return err
+		}
+		if workerConfig.Fusion {
+			if err := w.Fuse(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Initialize the local view before any update from peers is applied to it.
+	if err := tracker.PreProcess(); err != nil {
+		return err
+	}
+	if err := c.Serve(ctx, localHandles); err != nil {
+		return err
+	}
+	exchangeErr := make(chan error, 1)
+	go func() {
+		if err := x.Run(ctx); err != nil {
+			utils.Logger().Error(err.Error())
+			exchangeErr <- err
+			cancelFunc()
+		}
+	}()
+
+	if err := run(ctx, cancelFunc, workerConfig, tracker, workers); err != nil {
+		x.Abort(err)
+		return err
+	}
+	select {
+	case err := <-exchangeErr:
+		return err
+	default:
+		return nil
+	}
+}
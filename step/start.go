@@ -2,19 +2,148 @@ package step
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
 
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/tracing"
+	"github.com/stepneko/neko-dataflow/utils"
 	"github.com/stepneko/neko-dataflow/worker"
 )
 
 type StartFn = func(w worker.Worker) error
 
 func Start(fn StartFn) error {
+	return Execute(worker.DefaultConfig(), fn)
+}
+
+// Execute builds the dataflow with fn on every worker described by config,
+// then runs all workers concurrently. The workers share one progress tracker,
+// so notifications are only delivered once no worker can produce data for the timestamp.
+// If any worker fails, all workers are stopped and the first error is returned.
+func Execute(config worker.Config, fn StartFn) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
-	w := worker.NewSimpleWorker(ctx)
-	fn(w)
-	if err := w.Run(); err != nil {
+
+	tracker := graph.NewTracker(config.Workers)
+	tracker.SetCheck(config.CheckProgress)
+	workers := []*worker.SimpleWorker{}
+	peerHandles := []handles.WorkerHandle{}
+	for i := 0; i < config.Workers; i++ {
+		w := config.NewWorker(ctx, worker.Id(i), config.Workers, tracker)
+		workers = append(workers, w)
+		peerHandles = append(peerHandles, w.InboxHandle())
+	}
+	for _, w := range workers {
+		if err := w.ConnectPeers(peerHandles); err != nil {
+			return err
+		}
+		if err := fn(w); err != nil {
+			return err
+		}
+		if config.Fusion {
+			if err := w.Fuse(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return run(ctx, cancelFunc, config, tracker, workers)
+}
+
+// run runs the workers until they stop, together with the debug server and the watchdog
+// described by config. If any worker fails, all workers are stopped and the first error is returned.
+// If the watchdog aborts, all workers are stopped as well and the stall is returned once they did.
+func run(
+	ctx context.Context,
+	cancelFunc context.CancelFunc,
+	config worker.Config,
+	tracker *graph.Tracker,
+	workers []*worker.SimpleWorker,
+) error {
+	if config.DebugAddr != "" {
+		stop, err := serveDebug(config.DebugAddr, worker.NewDebugHandler(tracker, workers), config.Tracer)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runWorkers(cancelFunc, workers)
+	}()
+
+	// Stays nil unless the watchdog aborts, so that it is never selected.
+	var stalled chan error
+	if config.Watchdog > 0 {
+		logger := config.Logger
+		if logger == nil {
+			logger = utils.Logger()
+		}
+		d := worker.NewWatchdog(tracker, workers, config.Watchdog, config.WatchdogAbort, logger)
+		stalled = make(chan error, 1)
+		go func() {
+			if err := d.Run(ctx); err != nil {
+				stalled <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-done:
 		return err
+	case err := <-stalled:
+		// Blocked vertices and workers give up once the run is cancelled.
+		cancelFunc()
+		<-done
+		return err
+	}
+}
+
+// serveDebug starts the debug HTTP server on addr, and returns the function stopping it.
+// The trace recorded so far is served as well if tracing is enabled.
+func serveDebug(addr string, handler http.Handler, tracer *tracing.Recorder) (func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start debug server: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(worker.DebugPath, handler)
+	if tracer.Enabled() {
+		mux.Handle(tracing.Path, tracer.Handler())
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+	return func() { server.Close() }, nil
+}
+
+// runWorkers runs all workers concurrently until they stop.
+// If any worker fails, all workers are stopped and the first error is returned.
+func runWorkers(cancelFunc context.CancelFunc, workers []*worker.SimpleWorker) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker.SimpleWorker) {
+			defer wg.Done()
+			if err := w.Run(); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancelFunc()
+				})
+			}
+		}(w)
 	}
-	return nil
+	wg.Wait()
+
+	return firstErr
 }
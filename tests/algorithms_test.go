@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/algorithms"
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+type algorithmResult struct {
+	epoch int
+	v     string
+	val   string
+}
+
+// runAlgorithm feeds the edges of each epoch into the algorithm, spreading them
+// over the inputs of the workers, closes the inputs and collects n results of the algorithm.
+func runAlgorithm(
+	t *testing.T,
+	workers int,
+	algorithm func(edges operators.Operator) operators.EgressOp,
+	epochs [][]*request.Message,
+	n int,
+) map[int]map[string]string {
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	resultCh := make(chan algorithmResult, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		return w.Dataflow(func(s scope.Scope) error {
+			algorithm(operators.NewInput(s, ch)).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					v, val, err := algorithms.ParseResult(msg)
+					if err != nil {
+						return nil, err
+					}
+					resultCh <- algorithmResult{ts.Epoch, v, val}
+					return nil, nil
+				})
+			return nil
+		})
+	}
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	for epoch, edges := range epochs {
+		for i, e := range edges {
+			chs[i%workers] <- request.NewInputRaw(e, *timestamp.NewTimestampWithParams(epoch, []int{0}))
+		}
+	}
+	for _, ch := range chs {
+		close(ch)
+	}
+
+	res := make(map[int]map[string]string)
+	for i := 0; i < n; i++ {
+		r := <-resultCh
+		if _, exist := res[r.epoch]; !exist {
+			res[r.epoch] = make(map[string]string)
+		}
+		res[r.epoch][r.v] = r.val
+	}
+	return res
+}
+
+func TestConnectedComponents(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		res := runAlgorithm(
+			t,
+			workers,
+			algorithms.ConnectedComponents,
+			[][]*request.Message{
+				{
+					algorithms.NewEdge("c", "b"),
+					algorithms.NewEdge("b", "a"),
+					algorithms.NewEdge("e", "d"),
+					algorithms.NewEdge("f", "f"),
+				},
+				{
+					algorithms.NewEdge("y", "x"),
+				},
+			},
+			8,
+		)
+		assert.Equal(t, res[0], map[string]string{"a": "a", "b": "a", "c": "a", "d": "d", "e": "d", "f": "f"})
+		assert.Equal(t, res[1], map[string]string{"x": "x", "y": "x"})
+	}
+}
+
+func TestBFS(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		res := runAlgorithm(
+			t,
+			workers,
+			func(edges operators.Operator) operators.EgressOp {
+				return algorithms.BFS(edges, "a")
+			},
+			[][]*request.Message{
+				{
+					algorithms.NewEdge("a", "b"),
+					algorithms.NewEdge("b", "c"),
+					algorithms.NewEdge("a", "d"),
+					algorithms.NewEdge("d", "c"),
+					algorithms.NewEdge("c", "e"),
+					algorithms.NewEdge("f", "a"),
+				},
+			},
+			5,
+		)
+		assert.Equal(t, res[0], map[string]string{"a": "0", "b": "1", "d": "1", "c": "2", "e": "3"})
+	}
+}
+
+func TestShortestPaths(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		res := runAlgorithm(
+			t,
+			workers,
+			func(edges operators.Operator) operators.EgressOp {
+				return algorithms.ShortestPaths(edges, "a")
+			},
+			[][]*request.Message{
+				{
+					algorithms.NewWeightedEdge("a", "b", 4),
+					algorithms.NewWeightedEdge("a", "c", 1),
+					algorithms.NewWeightedEdge("c", "b", 2),
+					algorithms.NewWeightedEdge("b", "d", 1.5),
+				},
+			},
+			4,
+		)
+		assert.Equal(t, res[0], map[string]string{"a": "0", "c": "1", "b": "3", "d": "4.5"})
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		res := runAlgorithm(
+			t,
+			workers,
+			func(edges operators.Operator) operators.EgressOp {
+				return algorithms.PageRank(edges, 30, 0.85)
+			},
+			[][]*request.Message{
+				{
+					algorithms.NewEdge("b", "a"),
+					algorithms.NewEdge("c", "a"),
+					algorithms.NewEdge("a", "b"),
+				},
+			},
+			3,
+		)
+
+		ranks := make(map[string]float64)
+		sum := 0.0
+		for v, val := range res[0] {
+			r, err := strconv.ParseFloat(val, 64)
+			assert.Equal(t, err, nil)
+			ranks[v] = r
+			sum += r
+		}
+		assert.InDelta(t, sum, 1.0, 1e-9)
+		assert.Greater(t, ranks["a"], ranks["b"])
+		assert.Greater(t, ranks["b"], ranks["c"])
+		assert.InDelta(t, ranks["c"], 0.05, 1e-9)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckProgressCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		workers := 2
+		chs := []chan request.InputDatum{}
+		for i := 0; i < workers; i++ {
+			chs = append(chs, make(chan request.InputDatum, 1024))
+		}
+		inspectCh := make(chan string, 1024)
+
+		increment := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+			val, err := strconv.Atoi(msg.ToString())
+			if err != nil {
+				return nil, err
+			}
+			return iterator.IterFromSingleton(request.NewMessage([]byte(strconv.Itoa(val + 1)))), nil
+		}
+
+		f := func(w worker.Worker) error {
+			ch := chs[w.Index()]
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.NewInput(s, ch).
+					Loop(
+						func(ups operators.Operator) operators.Operator {
+							return ups.Inspect(increment)
+						},
+						func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+							val, err := strconv.Atoi(msg.ToString())
+							if err != nil {
+								return false, err
+							}
+							return val < 3, nil
+						},
+					).
+					SortBy(lessByInt).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						inspectCh <- msg.ToString()
+						return nil, nil
+					})
+				return nil
+			})
+		}
+
+		// A divergence of the counts stops the run with an error
+		config := worker.Config{Workers: workers, Scheduler: scheduler, CheckProgress: true}
+		done := make(chan error, 1)
+		go func() {
+			done <- step.Execute(config, f)
+		}()
+
+		for epoch := 0; epoch < 2; epoch++ {
+			for i := 0; i < workers; i++ {
+				chs[i] <- request.NewInputRaw(
+					request.NewMessage([]byte(strconv.Itoa(i))),
+					*timestamp.NewTimestampWithParams(epoch, []int{0}),
+				)
+			}
+		}
+		for i := 0; i < workers; i++ {
+			close(chs[i])
+		}
+
+		// The sort only emits an epoch once it is in the frontier
+		for epoch := 0; epoch < 2; epoch++ {
+			res := []string{}
+			for i := 0; i < workers; i++ {
+				res = append(res, <-inspectCh)
+			}
+			assert.Equal(t, []string{"3", "3"}, res)
+		}
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+		default:
+		}
+	}
+}
+
+func TestCheckProgressCorruptedCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.NewInput(s, ch).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						// Decrement a pointstamp which is not active, as a faulty operator would
+						vid := e.GetTarget()
+						s.GetWorkerHandle().Send(&request.Request{
+							Type: request.Type_DecreOC,
+							Edge: edge.NewEdge(vid, vid),
+							Msg:  *request.NewMessage([]byte{}),
+							Ts:   ts,
+						})
+						return nil, nil
+					})
+				return nil
+			})
+		}
+
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte("0")),
+			*timestamp.NewTimestamp(),
+		)
+
+		config := worker.Config{Workers: 1, Scheduler: scheduler, CheckProgress: true}
+		err := step.Execute(config, f)
+		assert.True(t, errors.Is(err, graph.ErrInvariant))
+		assert.EqualError(t, err, fmt.Sprintf(
+			"progress tracking invariant violated: cannot decrement pointstamp vertex 2 at %s: %s",
+			timestamp.NewTimestamp().ToString(),
+			"trying to decre a pointstamp which does not exist in active pointstamp map",
+		))
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/cluster"
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/progress"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+// loopbackAddresses returns n loopback addresses nothing listens on.
+func loopbackAddresses(t *testing.T, n int) []string {
+	res := []string{}
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		res = append(res, l.Addr().String())
+		l.Close()
+	}
+	return res
+}
+
+func TestClusterCase(t *testing.T) {
+	testCluster(t, func(p int) worker.Config {
+		return worker.DefaultConfig()
+	})
+}
+
+func TestClusterCooperativeCase(t *testing.T) {
+	registries := []*metrics.Registry{metrics.NewRegistry(), metrics.NewRegistry()}
+	testCluster(t, func(p int) worker.Config {
+		config := worker.DefaultConfig()
+		config.Scheduler = worker.Scheduler_Cooperative
+		config.Metrics = registries[p]
+		return config
+	})
+
+	// Every worker reports the message of its input into the registry of its process.
+	for p, registry := range registries {
+		for i := 0; i < 2; i++ {
+			labels := metrics.Labels{"worker": strconv.Itoa(p*2 + i), "vertex": "1", "type": "Input"}
+			assert.Equal(t, float64(1), registry.Counter("neko_operator_messages_sent_total", "", labels).Value())
+		}
+	}
+}
+
+// testCluster sorts the messages of all workers of two processes at one worker,
+// with the workers of each process built as described by the config for the process.
+func testCluster(t *testing.T, workerConfig func(p int) worker.Config) {
+	processes := 2
+	workers := 2
+	addresses := loopbackAddresses(t, processes)
+
+	chs := []chan request.InputDatum{}
+	for i := 0; i < processes*workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		assert.Equal(t, processes*workers, w.Peers())
+		ch := chs[w.Index()]
+		index := w.Index()
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Exchange(func(msg *request.Message) (string, error) {
+					return "all", nil
+				}).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("%d %s", index, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	for p := 0; p < processes; p++ {
+		config := cluster.DefaultConfig()
+		config.Process = p
+		config.Addresses = addresses
+		config.Workers = workers
+		config.Timeout = 5 * time.Second
+		go step.ExecuteCluster(config, workerConfig(p), f)
+	}
+
+	for i, ch := range chs {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(fmt.Sprintf("%d", 10-i))),
+			*timestamp.NewTimestamp(),
+		)
+		close(ch)
+	}
+
+	// All messages are exchanged to one worker, which only sorts them
+	// once every worker in both processes closed its input.
+	res := []string{}
+	owners := map[int]bool{}
+	for i := 0; i < processes*workers; i++ {
+		var index int
+		var msg string
+		fmt.Sscanf(<-inspectCh, "%d %s", &index, &msg)
+		owners[index] = true
+		res = append(res, msg)
+	}
+	assert.Equal(t, 1, len(owners))
+	assert.Equal(t, []string{"7", "8", "9", "10"}, res)
+}
+
+func TestClusterPeerFailureCase(t *testing.T) {
+
+	addresses := loopbackAddresses(t, 2)
+	ch := make(chan request.InputDatum, 1024)
+
+	errCh := make(chan error, 2)
+	for p := 0; p < 2; p++ {
+		config := cluster.DefaultConfig()
+		config.Process = p
+		config.Addresses = addresses
+		config.Workers = 1
+		config.Timeout = 5 * time.Second
+		config.Progress.FailureTimeout = 500 * time.Millisecond
+		go func(p int) {
+			errCh <- step.ExecuteCluster(config, worker.DefaultConfig(), func(w worker.Worker) error {
+				// Process 1 fails while building its dataflow.
+				if p == 1 {
+					return errors.New("cannot build dataflow")
+				}
+				return w.Dataflow(func(s scope.Scope) error {
+					operators.
+						NewInput(s, ch).
+						Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+							return nil, nil
+						})
+					return nil
+				})
+			})
+		}(p)
+	}
+
+	errs := []error{<-errCh, <-errCh}
+	var failed *progress.PeerFailedError
+	found := false
+	for _, err := range errs {
+		assert.Error(t, err)
+		if errors.As(err, &failed) {
+			found = true
+			assert.Equal(t, 1, failed.Process)
+		}
+	}
+	assert.True(t, found)
+}
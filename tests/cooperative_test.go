@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooperativeOrderCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	for i := 0; i < 3; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("inspect 1: %s", msg.ToString())
+					return iterator.IterFromSingleton(msg), nil
+				}).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("inspect 2: %s", msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: 1, Scheduler: worker.Scheduler_Cooperative}, f)
+
+	// Each pass steps the vertices in the order they were created,
+	// so every message goes through the whole chain before the next one is read.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, fmt.Sprintf("inspect 1: %d", i), <-inspectCh)
+		assert.Equal(t, fmt.Sprintf("inspect 2: %d", i), <-inspectCh)
+	}
+}
+
+func TestCooperativeLoopCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.NewInput(s, ch).
+				Loop(
+					func(ups operators.Operator) operators.Operator {
+						return ups.Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+							val, err := strconv.Atoi(msg.ToString())
+							if err != nil {
+								return nil, err
+							}
+							return iterator.IterFromSingleton(request.NewMessage([]byte(strconv.Itoa(val + 1)))), nil
+						})
+					},
+					func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+						val, err := strconv.Atoi(msg.ToString())
+						if err != nil {
+							return false, err
+						}
+						return val < 5, nil
+					},
+				).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: 1, Scheduler: worker.Scheduler_Cooperative}, f)
+
+	ch <- request.NewInputRaw(
+		request.NewMessage([]byte("0")),
+		*timestamp.NewTimestamp(),
+	)
+	assert.Equal(t, "5", <-inspectCh)
+}
+
+func TestCooperativeMultiWorkerCase(t *testing.T) {
+
+	workers := 2
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Exchange(keyByPrefix).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers, Scheduler: worker.Scheduler_Cooperative}, f)
+
+	for i := 0; i < 6; i++ {
+		chs[i%workers] <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	for _, ch := range chs {
+		close(ch)
+	}
+
+	res := []string{}
+	for i := 0; i < 6; i++ {
+		res = append(res, <-inspectCh)
+	}
+	sort.Strings(res)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, res)
+}
+
+func TestExecuteInvalidScheduler(t *testing.T) {
+	err := step.Execute(worker.Config{Workers: 1, Scheduler: worker.Scheduler(7)}, func(w worker.Worker) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugServerCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	addr := loopbackAddresses(t, 1)[0]
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: 1, DebugAddr: addr}, f)
+
+	for i := 0; i < 3; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestampWithParams(1, []int{0}),
+		)
+	}
+
+	// Epoch 1 stays open, so the sort operator holds its messages
+	// and waits for a notification.
+	var state worker.DebugState
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + worker.DebugPath)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		state = worker.DebugState{}
+		if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+			return false
+		}
+		return len(state.Workers) == 1 && state.Workers[0].Vertices[1].Ts != nil &&
+			state.Workers[0].Vertices[1].Ts.Epoch == 1 && len(state.Pointstamps) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []graph.VertexInfo{
+		{Id: 1, Type: "Input", Children: []vertex.Id{2}},
+		{Id: 2, Type: "Sort", Children: []vertex.Id{3}},
+		{Id: 3, Type: "Inspect", Children: []vertex.Id{}},
+	}, state.Vertices)
+
+	assert.Equal(t, []graph.PointstampInfo{
+		{Src: 1, Target: 1, Ts: *timestamp.NewTimestampWithParams(1, []int{0}), OC: 1, PC: 0},
+		{Src: 2, Target: 2, Ts: *timestamp.NewTimestampWithParams(1, []int{0}), OC: 1, PC: 1},
+	}, state.Pointstamps)
+
+	sort := state.Workers[0].Vertices[1]
+	assert.Equal(t, vertex.Id(2), sort.Id)
+	assert.Equal(t, "Sort", sort.Type)
+	assert.Equal(t, 0, sort.Queue)
+	assert.Greater(t, sort.Capacity, 0)
+	close(ch)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/events"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLogCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	var mu sync.Mutex
+	counts := make(map[events.Kind]int)
+	log := events.NewLog()
+	log.Subscribe(func(e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[e.Kind] += 1
+	})
+	var buf bytes.Buffer
+	jw := events.NewJSONWriter(&buf)
+	log.Subscribe(jw.Write)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: 1, Events: log}, f)
+
+	for i := 0; i < 3; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	for i := 0; i < 3; i++ {
+		<-inspectCh
+	}
+
+	// Once all messages are retired, every increment has a matching decrement.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[events.Kind_PointstampDecremented] == counts[events.Kind_PointstampIncremented]+1
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, 3, counts[events.Kind_OperatorCreated])
+	assert.Equal(t, 2, counts[events.Kind_EdgeCreated])
+	assert.Equal(t, 6, counts[events.Kind_MessageSent])
+	assert.Equal(t, 6, counts[events.Kind_MessageReceived])
+	assert.Equal(t, 1, counts[events.Kind_NotificationDelivered])
+	mu.Unlock()
+
+	assert.Nil(t, jw.Err())
+	res, err := events.ReadJSON(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, events.Kind_OperatorCreated, res[0].Kind)
+	assert.Equal(t, "Input", res[0].Type)
+}
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+// keyByPrefix groups messages like "a-0" by the part before the dash.
+func keyByPrefix(msg *request.Message) (string, error) {
+	return strings.Split(msg.ToString(), "-")[0], nil
+}
+
+func TestExchangeCase(t *testing.T) {
+
+	workers := 3
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		index := w.Index()
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Exchange(keyByPrefix).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("%d %s", index, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	// Every worker sends a message for every key.
+	for i, ch := range chs {
+		for _, k := range keys {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(fmt.Sprintf("%s-%d", k, i))),
+				*timestamp.NewTimestamp(),
+			)
+		}
+		close(ch)
+	}
+
+	owners := map[string]map[int]bool{}
+	for i := 0; i < workers*len(keys); i++ {
+		var index int
+		var msg string
+		fmt.Sscanf(<-inspectCh, "%d %s", &index, &msg)
+		k, _ := keyByPrefix(request.NewMessage([]byte(msg)))
+		if _, exist := owners[k]; !exist {
+			owners[k] = map[int]bool{}
+		}
+		owners[k][index] = true
+	}
+	assert.Equal(t, len(keys), len(owners))
+	for k, m := range owners {
+		assert.Equal(t, 1, len(m), "key %s processed by more than one worker", k)
+	}
+}
+
+func TestExchangeNotificationCase(t *testing.T) {
+
+	workers := 2
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Exchange(func(msg *request.Message) (string, error) {
+					return "all", nil
+				}).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	// All messages go to one worker, so the output is totally ordered.
+	for i, ch := range chs {
+		for _, v := range []int{4, 2, 0} {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(fmt.Sprintf("%d", v+i))),
+				*timestamp.NewTimestamp(),
+			)
+		}
+		close(ch)
+	}
+
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, fmt.Sprintf("%d", i), <-inspectCh)
+	}
+}
+
+func TestExchangeNilKeyCase(t *testing.T) {
+
+	workers := 2
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		index := w.Index()
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Exchange(nil).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("%d %s", index, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	// Without a key, every message stays on the worker reading it.
+	for i, ch := range chs {
+		for j := 0; j < 3; j++ {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(fmt.Sprintf("%d-%d", i, j))),
+				*timestamp.NewTimestamp(),
+			)
+		}
+		close(ch)
+	}
+
+	for i := 0; i < workers*3; i++ {
+		var index int
+		var msg string
+		fmt.Sscanf(<-inspectCh, "%d %s", &index, &msg)
+		assert.Equal(t, fmt.Sprintf("%d", index), strings.Split(msg, "-")[0])
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFusionLoopCase(t *testing.T) {
+
+	workers := 2
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	increment := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+		val, err := strconv.Atoi(msg.ToString())
+		if err != nil {
+			return nil, err
+		}
+		return iterator.IterFromSingleton(request.NewMessage([]byte(strconv.Itoa(val + 1)))), nil
+	}
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.NewInput(s, ch).
+				Loop(
+					func(ups operators.Operator) operators.Operator {
+						// Both increments are fused into one vertex inside the loop.
+						return ups.Inspect(increment).Inspect(increment)
+					},
+					func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+						val, err := strconv.Atoi(msg.ToString())
+						if err != nil {
+							return false, err
+						}
+						return val < 6, nil
+					},
+				).
+				Inspect(increment).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers, Fusion: true}, f)
+
+	chs[0] <- request.NewInputRaw(request.NewMessage([]byte("0")), *timestamp.NewTimestamp())
+	chs[1] <- request.NewInputRaw(request.NewMessage([]byte("1")), *timestamp.NewTimestamp())
+	for _, ch := range chs {
+		close(ch)
+	}
+
+	res := []string{<-inspectCh, <-inspectCh}
+	sort.Strings(res)
+	assert.Equal(t, []string{"7", "8"}, res)
+}
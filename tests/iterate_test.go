@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterateCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	stepCh := make(chan string, 1024)
+	resultCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				// Sum up numbers pairwise, one level of the tree per iteration.
+				// Every iteration must see all the sums of the previous one.
+				Iterate(func(iteration int, ts timestamp.Timestamp, msgs []*request.Message) (iterator.Iterator[*request.Message], bool, error) {
+					vals := []int{}
+					for _, msg := range msgs {
+						val, err := strconv.Atoi(msg.ToString())
+						if err != nil {
+							return nil, false, err
+						}
+						vals = append(vals, val)
+					}
+					sort.Ints(vals)
+					stepCh <- fmt.Sprintf("iteration %d: %v", iteration, vals)
+
+					if len(vals) == 1 {
+						return iterator.IterFromArray(msgs), true, nil
+					}
+					next := []*request.Message{}
+					for i := 0; i < len(vals); i += 2 {
+						sum := vals[i]
+						if i+1 < len(vals) {
+							sum += vals[i+1]
+						}
+						next = append(next, request.NewMessage([]byte(strconv.Itoa(sum))))
+					}
+					return iterator.IterFromArray(next), false, nil
+				}).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					resultCh <- fmt.Sprintf("result %s %v", msg.ToString(), ts.Counters)
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	for i := 1; i <= 8; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	assert.Equal(t, <-stepCh, "iteration 0: [1 2 3 4 5 6 7 8]")
+	assert.Equal(t, <-stepCh, "iteration 1: [3 7 11 15]")
+	assert.Equal(t, <-stepCh, "iteration 2: [10 26]")
+	assert.Equal(t, <-stepCh, "iteration 3: [36]")
+	assert.Equal(t, <-resultCh, "result 36 [0]")
+}
+
+func TestIterateMaxIterationsCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	resultCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Iterate(
+					func(iteration int, ts timestamp.Timestamp, msgs []*request.Message) (iterator.Iterator[*request.Message], bool, error) {
+						next := []*request.Message{}
+						for _, msg := range msgs {
+							val, err := strconv.Atoi(msg.ToString())
+							if err != nil {
+								return nil, false, err
+							}
+							next = append(next, request.NewMessage([]byte(strconv.Itoa(val*2))))
+						}
+						// Never done by itself
+						return iterator.IterFromArray(next), false, nil
+					},
+					operators.WithMaxIterations(4),
+				).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					resultCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	ch <- request.NewInputRaw(request.NewMessage([]byte("1")), *timestamp.NewTimestamp())
+	close(ch)
+
+	assert.Equal(t, <-resultCh, "16")
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+		core, logs := observer.New(zapcore.WarnLevel)
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.
+					NewInput(s, ch).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						return nil, errors.New("inspect failed")
+					})
+				return nil
+			})
+		}
+
+		go step.Execute(worker.Config{Workers: 1, Scheduler: scheduler, Logger: zap.New(core)}, f)
+
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte("0")),
+			*timestamp.NewTimestamp(),
+		)
+
+		assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, time.Millisecond)
+		entry := logs.All()[0]
+		assert.Equal(t, "inspect failed", entry.Message)
+		assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+		assert.Equal(t, map[string]interface{}{
+			"worker": int64(0),
+			"vertex": int64(2),
+			"type":   "Inspect",
+		}, entry.ContextMap())
+		close(ch)
+	}
+}
@@ -80,3 +80,114 @@ func TestLoopCase(t *testing.T) {
 	s3 := <-inspectCh3
 	assert.Equal(t, s3, fmt.Sprintf("inspect operator 3 outside loop received message: %d", 5))
 }
+
+func TestLoopMaxIterationsCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh1 := make(chan string, 1024)
+	inspectCh2 := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			input := operators.NewInput(s, ch)
+			input.Loop(
+				func(ups operators.Operator) operators.Operator {
+					return ups.
+						Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+							inspectCh1 <- fmt.Sprintf("iteration %d: %s", ts.Counters[1], msg.ToString())
+							val, err := strconv.Atoi(msg.ToString())
+							if err != nil {
+								return nil, err
+							}
+							return iterator.IterFromSingleton(request.NewMessage([]byte(strconv.Itoa(val + 1)))), nil
+						})
+				},
+				// A buggy filter which never lets the message leave the loop
+				func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					return true, nil
+				},
+				operators.WithMaxIterations(3),
+			).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh2 <- fmt.Sprintf("outside loop: %s", msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	ch <- request.NewInputRaw(
+		request.NewMessage([]byte("0")),
+		*timestamp.NewTimestamp(),
+	)
+
+	for i := 0; i < 3; i++ {
+		s1 := <-inspectCh1
+		assert.Equal(t, s1, fmt.Sprintf("iteration %d: %d", i, i))
+	}
+	s2 := <-inspectCh2
+	assert.Equal(t, s2, "outside loop: 3")
+}
+
+func TestLoopFixedPointCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			input := operators.NewInput(s, ch)
+			input.Loop(
+				func(ups operators.Operator) operators.Operator {
+					// Every number reaches itself and its successor up to 4,
+					// so the fixed point is all numbers from the input up to 4.
+					return ups.
+						Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+							val, err := strconv.Atoi(msg.ToString())
+							if err != nil {
+								return nil, err
+							}
+							arr := []*request.Message{request.NewMessage([]byte(strconv.Itoa(val)))}
+							if val < 4 {
+								arr = append(arr, request.NewMessage([]byte(strconv.Itoa(val+1))))
+							}
+							return iterator.IterFromArray(arr), nil
+						})
+				},
+				nil,
+				operators.WithFixedPoint(),
+			).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("epoch %d: %s", ts.Epoch, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	ch <- request.NewInputRaw(
+		request.NewMessage([]byte("0")),
+		*timestamp.NewTimestamp(),
+	)
+	ch <- request.NewInputRaw(
+		request.NewMessage([]byte("3")),
+		*timestamp.NewTimestampWithParams(1, []int{0}),
+	)
+	close(ch)
+
+	// Epochs reach their fixed points independently, so only
+	// the order within each epoch is deterministic.
+	epochs := map[int][]string{}
+	for i := 0; i < 7; i++ {
+		var epoch int
+		var val string
+		fmt.Sscanf(<-inspectCh, "epoch %d: %s", &epoch, &val)
+		epochs[epoch] = append(epochs[epoch], val)
+	}
+	assert.Equal(t, epochs[0], []string{"0", "1", "2", "3", "4"})
+	assert.Equal(t, epochs[1], []string{"3", "4"})
+}
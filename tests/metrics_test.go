@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+	registry := metrics.NewRegistry()
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Filter(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					return msg.ToString() != "2", nil
+				}).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: 1, Metrics: registry}, f)
+
+	for i := 0; i < 5; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	for i := 0; i < 4; i++ {
+		<-inspectCh
+	}
+
+	labels := func(vid int, typ string) metrics.Labels {
+		return metrics.Labels{"worker": "0", "vertex": strconv.Itoa(vid), "type": typ}
+	}
+	received := func(vid int, typ string) float64 {
+		return registry.Counter("neko_operator_messages_received_total", "", labels(vid, typ)).Value()
+	}
+	sent := func(vid int, typ string) float64 {
+		return registry.Counter("neko_operator_messages_sent_total", "", labels(vid, typ)).Value()
+	}
+
+	assert.Equal(t, float64(5), sent(1, "Input"))
+	assert.Equal(t, float64(5), received(2, "Filter"))
+	assert.Equal(t, float64(4), sent(2, "Filter"))
+	assert.Equal(t, float64(4), received(3, "Sort"))
+	// The sort operator may still be finishing its notification
+	assert.Eventually(t, func() bool {
+		notified := registry.Counter("neko_operator_notifications_total", "", labels(3, "Sort")).Value()
+		return notified == 1 && sent(3, "Sort") == 4
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(5), registry.Histogram("neko_operator_callback_seconds", "", labels(2, "Filter"), nil).Count())
+
+	var buf bytes.Buffer
+	assert.Nil(t, registry.WriteText(&buf))
+	text := buf.String()
+	assert.Contains(t, text, "# TYPE neko_operator_queue_length gauge\n")
+	assert.Contains(t, text, "# TYPE neko_tracker_active_pointstamps gauge\n")
+	assert.Contains(t, text, `neko_worker_requests_total{type="SendBy",worker="0"}`)
+	assert.Contains(t, text, `neko_worker_notifications_delivered_total{worker="0"} 1`)
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiWorkerCase(t *testing.T) {
+
+	workers := 3
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		assert.Equal(t, workers, w.Peers())
+		ch := chs[w.Index()]
+		index := w.Index()
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("worker %d: %s", index, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	for i, ch := range chs {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+		close(ch)
+	}
+
+	res := []string{}
+	for i := 0; i < workers; i++ {
+		res = append(res, <-inspectCh)
+	}
+	sort.Strings(res)
+	assert.Equal(t, []string{"worker 0: 0", "worker 1: 1", "worker 2: 2"}, res)
+}
+
+func TestMultiWorkerNotificationCase(t *testing.T) {
+
+	workers := 2
+	chs := []chan request.InputDatum{}
+	for i := 0; i < workers; i++ {
+		chs = append(chs, make(chan request.InputDatum, 1024))
+	}
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		ch := chs[w.Index()]
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	go step.Execute(worker.Config{Workers: workers}, f)
+
+	for _, i := range []int{2, 0} {
+		chs[0] <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(chs[0])
+
+	// Worker 1 still holds epoch 0 open, so worker 0 must not be notified.
+	select {
+	case s := <-inspectCh:
+		t.Fatalf("unexpected output before all inputs are closed: %s", s)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	chs[1] <- request.NewInputRaw(
+		request.NewMessage([]byte("1")),
+		*timestamp.NewTimestamp(),
+	)
+	close(chs[1])
+
+	res := []string{}
+	for i := 0; i < 3; i++ {
+		res = append(res, <-inspectCh)
+	}
+	sort.Strings(res)
+	assert.Equal(t, []string{"0", "1", "2"}, res)
+}
+
+func TestExecuteInvalidConfig(t *testing.T) {
+	err := step.Execute(worker.Config{Workers: 0}, func(w worker.Worker) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNamesCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+		core, logs := observer.New(zapcore.WarnLevel)
+		registry := metrics.NewRegistry()
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.
+					NewInput(s, ch, operators.WithName("source")).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						return nil, errors.New("parse failed")
+					}, operators.WithName("parse"))
+				return nil
+			})
+		}
+
+		config := worker.Config{Workers: 1, Scheduler: scheduler, Logger: zap.New(core), Metrics: registry}
+		go step.Execute(config, f)
+
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte("0")),
+			*timestamp.NewTimestamp(),
+		)
+
+		assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, time.Millisecond)
+		entry := logs.All()[0]
+		assert.Equal(t, "parse failed", entry.Message)
+		assert.Equal(t, map[string]interface{}{
+			"worker": int64(0),
+			"vertex": int64(2),
+			"type":   "Inspect",
+			"name":   "parse",
+		}, entry.ContextMap())
+
+		labels := metrics.Labels{"worker": "0", "vertex": "2", "type": "Inspect", "name": "parse"}
+		assert.Equal(t, float64(1), registry.Counter("neko_operator_errors_total", "", labels).Value())
+		labels = metrics.Labels{"worker": "0", "vertex": "1", "type": "Input", "name": "source"}
+		assert.Equal(t, float64(1), registry.Counter("neko_operator_messages_sent_total", "", labels).Value())
+		close(ch)
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+		inspectCh := make(chan string, 1024)
+		registry := metrics.NewRegistry()
+		probe := operators.NewLatencyProbe("sink")
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.
+					NewInput(s, ch, operators.WithProbe(probe)).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						time.Sleep(10 * time.Millisecond)
+						return iterator.IterFromSingleton(msg), nil
+					}).
+					Probe(probe).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						inspectCh <- msg.ToString()
+						return nil, nil
+					})
+				return nil
+			})
+		}
+
+		go step.Execute(worker.Config{Workers: 1, Scheduler: scheduler, Metrics: registry}, f)
+
+		for epoch := 0; epoch < 3; epoch++ {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(strconv.Itoa(epoch))),
+				*timestamp.NewTimestampWithParams(epoch, []int{0}),
+			)
+		}
+		for i := 0; i < 3; i++ {
+			<-inspectCh
+		}
+
+		opened, exist := probe.Opened(0)
+		assert.True(t, exist)
+		assert.False(t, opened.IsZero())
+		_, exist = probe.Opened(3)
+		assert.False(t, exist)
+
+		// Epochs 0 and 1 are complete once the input opens the next epoch,
+		// and epoch 2 once the input is closed.
+		latency := registry.Histogram(
+			"neko_epoch_latency_seconds", "",
+			metrics.Labels{"probe": "sink", "worker": "0", "vertex": "3"},
+			nil,
+		)
+		assert.Eventually(t, func() bool { return latency.Count() == 2 }, time.Second, time.Millisecond)
+		close(ch)
+		assert.Eventually(t, func() bool { return latency.Count() == 3 }, time.Second, time.Millisecond)
+		// Every message spends at least 10ms in the inspect
+		assert.GreaterOrEqual(t, latency.Sum(), 0.03)
+	}
+}
+
+func TestProbeFilteredCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+		registry := metrics.NewRegistry()
+		probe := operators.NewLatencyProbe("sink")
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.
+					NewInput(s, ch, operators.WithProbe(probe)).
+					Filter(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+						return false, nil
+					}).
+					Probe(probe)
+				return nil
+			})
+		}
+
+		go step.Execute(worker.Config{Workers: 1, Scheduler: scheduler, Metrics: registry}, f)
+
+		for epoch := 0; epoch < 3; epoch++ {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(strconv.Itoa(epoch))),
+				*timestamp.NewTimestampWithParams(epoch, []int{0}),
+			)
+		}
+		close(ch)
+
+		// No message reaches the probe, but every epoch opened by the input is reported.
+		latency := registry.Histogram(
+			"neko_epoch_latency_seconds", "",
+			metrics.Labels{"probe": "sink", "worker": "0", "vertex": "3"},
+			nil,
+		)
+		assert.Eventually(t, func() bool { return latency.Count() == 3 }, time.Second, time.Millisecond)
+		assert.Never(t, func() bool { return latency.Count() > 3 }, 50*time.Millisecond, time.Millisecond)
+	}
+}
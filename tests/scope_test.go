@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func increment(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+	val, err := strconv.Atoi(msg.ToString())
+	if err != nil {
+		return nil, err
+	}
+	return iterator.IterFromSingleton(request.NewMessage([]byte(strconv.Itoa(val + 1)))), nil
+}
+
+func TestScopeMultipleEntriesAndExits(t *testing.T) {
+
+	ch1 := make(chan request.InputDatum, 1024)
+	ch2 := make(chan request.InputDatum, 1024)
+	exitCh1 := make(chan string, 1024)
+	exitCh2 := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			input1 := operators.NewInput(s, ch1)
+			input2 := operators.NewInput(s, ch2)
+
+			loop := operators.NewSubScope(s, "loop")
+			assert.Equal(t, loop.Name(), "worker 0/loop")
+			assert.Equal(t, loop.Depth(), 1)
+
+			feedback := loop.Feedback()
+			body := loop.Enter(input1).
+				Concat(loop.Enter(input2)).
+				Concat(feedback).
+				Inspect(increment).
+				Branch(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					val, err := strconv.Atoi(msg.ToString())
+					if err != nil {
+						return false, err
+					}
+					return val%10 < 3, nil
+				})
+			feedback.Connect(body)
+
+			exits := body.Else().
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					iteration, err := loop.Iteration(ts)
+					if err != nil {
+						return nil, err
+					}
+					return iterator.IterFromSingleton(request.NewMessage([]byte(fmt.Sprintf("%s@%d", msg.ToString(), iteration)))), nil
+				}).
+				Branch(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					return msg.ToString()[0] != '1', nil
+				})
+
+			loop.Leave(exits).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					exitCh1 <- fmt.Sprintf("%s %v", msg.ToString(), ts.Counters)
+					return nil, nil
+				})
+			loop.Leave(exits.Else()).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					exitCh2 <- fmt.Sprintf("%s %v", msg.ToString(), ts.Counters)
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	ch1 <- request.NewInputRaw(request.NewMessage([]byte("0")), *timestamp.NewTimestamp())
+	ch2 <- request.NewInputRaw(request.NewMessage([]byte("100")), *timestamp.NewTimestamp())
+
+	assert.Equal(t, <-exitCh1, "3@2 [0]")
+	assert.Equal(t, <-exitCh2, "103@2 [0]")
+}
+
+func TestNestedScopes(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	innerCh := make(chan string, 1024)
+	outCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			input := operators.NewInput(s, ch)
+
+			outer := operators.NewSubScope(s, "outer")
+			outerFeedback := outer.Feedback()
+			outerBody := outer.Enter(input).Concat(outerFeedback)
+
+			inner := operators.NewSubScope(outer, "inner")
+			assert.Equal(t, inner.Name(), "worker 0/outer/inner")
+			assert.Equal(t, inner.Depth(), 2)
+
+			innerFeedback := inner.Feedback()
+			innerBody := inner.Enter(outerBody).
+				Concat(innerFeedback).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					o, err := outer.Iteration(ts)
+					if err != nil {
+						return nil, err
+					}
+					i, err := inner.Iteration(ts)
+					if err != nil {
+						return nil, err
+					}
+					innerCh <- fmt.Sprintf("outer %d inner %d: %s", o, i, msg.ToString())
+					return increment(e, msg, ts)
+				}).
+				Branch(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					i, err := inner.Iteration(ts)
+					return i < 2, err
+				})
+			innerFeedback.Connect(innerBody)
+
+			outerTail := inner.Leave(innerBody.Else()).
+				Branch(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+					o, err := outer.Iteration(ts)
+					return o < 1, err
+				})
+			outerFeedback.Connect(outerTail)
+
+			outer.Leave(outerTail.Else()).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					outCh <- fmt.Sprintf("%s %v", msg.ToString(), ts.Counters)
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+	go step.Start(f)
+
+	ch <- request.NewInputRaw(request.NewMessage([]byte("0")), *timestamp.NewTimestamp())
+
+	for o := 0; o < 2; o++ {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, <-innerCh, fmt.Sprintf("outer %d inner %d: %d", o, i, o*3+i))
+		}
+	}
+	assert.Equal(t, <-outCh, "6 [0]")
+}
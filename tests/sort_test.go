@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func lessByInt(a *request.Message, b *request.Message) bool {
+	va, _ := strconv.Atoi(a.ToString())
+	vb, _ := strconv.Atoi(b.ToString())
+	return va < vb
+}
+
+func TestSortCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("epoch %d: %s", ts.Epoch, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+
+	go step.Start(f)
+
+	for _, i := range []int{3, 1, 4, 0, 2} {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	for _, i := range []int{13, 12, 10, 11} {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestampWithParams(1, []int{0}),
+		)
+	}
+	close(ch)
+
+	for i := 0; i < 5; i++ {
+		s := <-inspectCh
+		assert.Equal(t, s, fmt.Sprintf("epoch 0: %d", i))
+	}
+	for i := 10; i < 14; i++ {
+		s := <-inspectCh
+		assert.Equal(t, s, fmt.Sprintf("epoch 1: %d", i))
+	}
+}
+
+func TestSortWaitsForEpochCompletion(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					return iterator.IterFromSingleton(msg), nil
+				}).
+				SortBy(lessByInt).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+
+	go step.Start(f)
+
+	for _, i := range []int{2, 0, 1} {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+
+	select {
+	case s := <-inspectCh:
+		t.Fatalf("sort emitted %s before epoch 0 was complete", s)
+	default:
+	}
+
+	// A datum with nil message only advances the epoch of the input.
+	ch <- request.NewInputRaw(nil, *timestamp.NewTimestampWithParams(1, []int{0}))
+
+	for i := 0; i < 3; i++ {
+		s := <-inspectCh
+		assert.Equal(t, s, strconv.Itoa(i))
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopKCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				TopK(3, lessByInt, nil).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- fmt.Sprintf("epoch %d: %s", ts.Epoch, msg.ToString())
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+
+	go step.Start(f)
+
+	for _, i := range []int{7, 3, 9, 1, 8, 2, 6} {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	for _, i := range []int{1, 2, 3} {
+		s := <-inspectCh
+		assert.Equal(t, s, fmt.Sprintf("epoch 0: %d", i))
+	}
+}
+
+func TestTopKPerKeyCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	valueOf := func(msg *request.Message) int {
+		val, _ := strconv.Atoi(strings.Split(msg.ToString(), ":")[1])
+		return val
+	}
+
+	f := func(w worker.Worker) error {
+		w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				TopK(
+					2,
+					func(a *request.Message, b *request.Message) bool {
+						// Largest values first
+						return valueOf(a) > valueOf(b)
+					},
+					func(msg *request.Message) (string, error) {
+						return strings.Split(msg.ToString(), ":")[0], nil
+					},
+				).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+		return nil
+	}
+
+	go step.Start(f)
+
+	for _, s := range []string{"b:1", "a:5", "b:7", "a:2", "a:9", "b:4", "a:1"} {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(s)),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(ch)
+
+	for _, expected := range []string{"a:9", "a:5", "b:7", "b:4"} {
+		s := <-inspectCh
+		assert.Equal(t, s, expected)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/tracing"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracingCase(t *testing.T) {
+	for _, scheduler := range []worker.Scheduler{worker.Scheduler_Threaded, worker.Scheduler_Cooperative} {
+
+		ch := make(chan request.InputDatum, 1024)
+		inspectCh := make(chan string, 1024)
+		tracer := tracing.NewRecorder()
+
+		f := func(w worker.Worker) error {
+			return w.Dataflow(func(s scope.Scope) error {
+				operators.
+					NewInput(s, ch).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						return iterator.IterFromSingleton(msg), nil
+					}, operators.WithName("identity")).
+					Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+						inspectCh <- msg.ToString()
+						return nil, nil
+					})
+				return nil
+			})
+		}
+
+		go step.Execute(worker.Config{Workers: 1, Scheduler: scheduler, Tracer: tracer}, f)
+
+		for i := 0; i < 3; i++ {
+			ch <- request.NewInputRaw(
+				request.NewMessage([]byte(strconv.Itoa(i))),
+				*timestamp.NewTimestamp(),
+			)
+		}
+		for i := 0; i < 3; i++ {
+			<-inspectCh
+		}
+
+		// Count the spans of each vertex by name, with the messages they sent
+		count := func() map[vertex.Id]map[string][2]int {
+			res := map[vertex.Id]map[string][2]int{}
+			for _, s := range tracer.Spans() {
+				if _, exist := res[s.Vertex]; !exist {
+					res[s.Vertex] = map[string][2]int{}
+				}
+				c := res[s.Vertex][s.Name]
+				res[s.Vertex][s.Name] = [2]int{c[0] + 1, c[1] + s.Messages}
+			}
+			return res
+		}
+		assert.Eventually(t, func() bool {
+			c := count()
+			return c[1]["Input"] == [2]int{3, 3} &&
+				c[2]["OnRecv"] == [2]int{3, 3} &&
+				c[3]["OnRecv"] == [2]int{3, 0} &&
+				c[vertex.Id_Nil]["SendBy"] == [2]int{6, 6}
+		}, time.Second, time.Millisecond)
+
+		names := map[int]string{}
+		for _, e := range tracer.Trace().TraceEvents {
+			if e.Name == "thread_name" {
+				names[e.Tid] = e.Args["name"].(string)
+			}
+		}
+		assert.Equal(t, map[int]string{0: "worker 0", 1: "Input 1", 2: "Inspect 2 (identity)", 3: "Inspect 3"}, names)
+		close(ch)
+	}
+}
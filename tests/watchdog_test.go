@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/constants"
+	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/iterator"
+	"github.com/stepneko/neko-dataflow/operators"
+	"github.com/stepneko/neko-dataflow/request"
+	"github.com/stepneko/neko-dataflow/scope"
+	"github.com/stepneko/neko-dataflow/step"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+	"github.com/stepneko/neko-dataflow/worker"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// releaseOnStall closes release once the watchdog logged a stall. Execute waits for
+// the workers to stop after the watchdog aborts, which needs the stuck callbacks to return.
+func releaseOnStall(logs *observer.ObservedLogs, release chan struct{}) {
+	for logs.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+}
+
+func TestWatchdogBusyCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+	core, logs := observer.New(zapcore.WarnLevel)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					// Much longer than the watchdog period, while the others wait in the channel
+					time.Sleep(50 * time.Millisecond)
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+
+	config := worker.Config{
+		Workers:       1,
+		Logger:        zap.New(core),
+		Watchdog:      10 * time.Millisecond,
+		WatchdogAbort: true,
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- step.Execute(config, f)
+	}()
+
+	// A long callback with requests waiting is busy, not stalled
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-inspectCh:
+			assert.Equal(t, strconv.Itoa(i), msg)
+		case err := <-done:
+			t.Fatalf("unexpected stall: %v", err)
+		}
+	}
+	assert.Equal(t, 0, logs.Len())
+	close(ch)
+}
+
+func TestWatchdogFullChannelCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 4*constants.ChanCapacity)
+	release := make(chan struct{})
+	core, logs := observer.New(zapcore.WarnLevel)
+	go releaseOnStall(logs, release)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					<-release
+					return nil, nil
+				}, operators.WithName("stuck"))
+			return nil
+		})
+	}
+
+	// More messages than the channel of the inspect can hold
+	for i := 0; i < 3*constants.ChanCapacity; i++ {
+		ch <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+
+	config := worker.Config{
+		Workers:       1,
+		Logger:        zap.New(core),
+		Watchdog:      50 * time.Millisecond,
+		WatchdogAbort: true,
+	}
+	err := step.Execute(config, f)
+
+	var stall *worker.Stall
+	assert.True(t, errors.As(err, &stall))
+	ws := stall.Workers[0]
+	assert.True(t, ws.Blocked)
+	// The worker waits for room in the channel of the inspect, while the input
+	// waits for the ack of the request left in the inbox of the worker
+	assert.Equal(t, 1, ws.Inbox)
+	assert.Equal(t, 2, len(ws.Vertices))
+	assert.Equal(t, vertex.Id(1), ws.Vertices[0].Id)
+	assert.True(t, ws.Vertices[0].Blocked)
+	assert.Equal(t, vertex.Id(2), ws.Vertices[1].Id)
+	assert.Equal(t, constants.ChanCapacity, ws.Vertices[1].Queue)
+	assert.Equal(t,
+		"dataflow made no progress for 50ms: blocked [worker 0, worker 0 Input 1], "+
+			"full channels [worker 0 Inspect 2 (stuck)], 2 active pointstamps",
+		err.Error(),
+	)
+}
+
+func TestWatchdogIdleCase(t *testing.T) {
+
+	ch := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+
+	f := func(w worker.Worker) error {
+		return w.Dataflow(func(s scope.Scope) error {
+			operators.
+				NewInput(s, ch).
+				Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+					inspectCh <- msg.ToString()
+					return nil, nil
+				})
+			return nil
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- step.Execute(worker.Config{Workers: 1, Watchdog: 10 * time.Millisecond, WatchdogAbort: true}, f)
+	}()
+
+	ch <- request.NewInputRaw(
+		request.NewMessage([]byte("0")),
+		*timestamp.NewTimestamp(),
+	)
+	assert.Equal(t, "0", <-inspectCh)
+
+	// Waiting for more input is not a stall
+	assert.Never(t, func() bool { return len(done) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	close(ch)
+}
@@ -9,8 +9,8 @@ import (
 )
 
 type Timestamp struct {
-	Epoch    int
-	Counters []int
+	Epoch    int   `json:"epoch"`
+	Counters []int `json:"counters"`
 }
 
 func NewTimestamp() *Timestamp {
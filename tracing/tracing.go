@@ -0,0 +1,201 @@
+// Package tracing records spans of the work done by operators and workers, and writes
+// them in the trace event format of Chrome, which can be opened in Perfetto or about:tracing.
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// Path is the path the debug server serves the trace at.
+const Path = "/debug/trace"
+
+// Span is a piece of work done by a vertex, such as running the callback of a message,
+// or by a worker, such as handling a request from a vertex.
+type Span struct {
+	// Name of the work, such as the type of the request handled.
+	Name   string
+	Worker int
+	// The vertex doing the work, Id_Nil if the work is done by the worker itself.
+	Vertex vertex.Id
+	Start  time.Time
+	End    time.Time
+	// Timestamp of the request handled, nil if none.
+	Ts *timestamp.Timestamp
+	// Number of messages sent or delivered during the span.
+	Messages int
+}
+
+// Event is an event of the trace event format. Only complete events, which describe
+// a span, and metadata events, which name processes and threads, are written.
+type Event struct {
+	Name string `json:"name"`
+	Cat  string `json:"cat,omitempty"`
+	Ph   string `json:"ph"`
+	// Start and duration of the span in microseconds since the recorder was created.
+	Ts   float64                `json:"ts"`
+	Dur  float64                `json:"dur,omitempty"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// Trace is the JSON object written by Recorder.WriteJSON.
+type Trace struct {
+	TraceEvents     []Event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit"`
+}
+
+// thread is a vertex of a worker. Each worker is drawn as a process,
+// and each of its vertices as a thread. The worker itself is thread 0.
+type thread struct {
+	worker int
+	vertex vertex.Id
+}
+
+// Recorder collects the spans of all workers and operators of a process.
+// It keeps every span in memory until the trace is written, so it is meant for
+// investigating runs of limited length. All methods can be called on a nil recorder,
+// which records nothing.
+type Recorder struct {
+	mu      sync.Mutex
+	origin  time.Time
+	spans   []Span
+	threads map[thread]string
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		origin:  time.Now(),
+		spans:   []Span{},
+		threads: make(map[thread]string),
+	}
+}
+
+// Enabled tells whether spans are recorded, so that callers can skip measuring them.
+func (r *Recorder) Enabled() bool {
+	return r != nil
+}
+
+// Record adds a span to the trace.
+func (r *Recorder) Record(s Span) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+// NameThread sets the name the spans of a vertex of a worker are shown under.
+func (r *Recorder) NameThread(worker int, vid vertex.Id, name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.threads[thread{worker: worker, vertex: vid}] = name
+}
+
+// Spans returns the spans recorded so far.
+func (r *Recorder) Spans() []Span {
+	if r == nil {
+		return []Span{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Span{}, r.spans...)
+}
+
+// Trace returns the spans recorded so far as trace events, after the metadata events
+// naming every worker and vertex.
+func (r *Recorder) Trace() Trace {
+	res := Trace{
+		TraceEvents:     []Event{},
+		DisplayTimeUnit: "ms",
+	}
+	if r == nil {
+		return res
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	threads := []thread{}
+	for t := range r.threads {
+		threads = append(threads, t)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		if threads[i].worker != threads[j].worker {
+			return threads[i].worker < threads[j].worker
+		}
+		return threads[i].vertex < threads[j].vertex
+	})
+	workers := map[int]bool{}
+	for _, t := range threads {
+		if !workers[t.worker] {
+			workers[t.worker] = true
+			res.TraceEvents = append(res.TraceEvents, Event{
+				Name: "process_name",
+				Ph:   "M",
+				Pid:  t.worker,
+				Args: map[string]interface{}{"name": fmt.Sprintf("worker %d", t.worker)},
+			})
+		}
+		res.TraceEvents = append(res.TraceEvents, Event{
+			Name: "thread_name",
+			Ph:   "M",
+			Pid:  t.worker,
+			Tid:  int(t.vertex),
+			Args: map[string]interface{}{"name": r.threads[t]},
+		})
+	}
+
+	for _, s := range r.spans {
+		cat := "operator"
+		if s.Vertex == vertex.Id_Nil {
+			cat = "worker"
+		}
+		args := map[string]interface{}{"messages": s.Messages}
+		if s.Ts != nil {
+			args["timestamp"] = s.Ts.ToString()
+		}
+		res.TraceEvents = append(res.TraceEvents, Event{
+			Name: s.Name,
+			Cat:  cat,
+			Ph:   "X",
+			Ts:   micros(s.Start.Sub(r.origin)),
+			Dur:  micros(s.End.Sub(s.Start)),
+			Pid:  s.Worker,
+			Tid:  int(s.Vertex),
+			Args: args,
+		})
+	}
+	return res
+}
+
+// WriteJSON writes the trace as JSON, see Trace.
+func (r *Recorder) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Trace())
+}
+
+// Handler returns an HTTP handler serving the trace recorded so far.
+func (r *Recorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := r.WriteJSON(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
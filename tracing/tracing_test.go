@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderTrace(t *testing.T) {
+	r := NewRecorder()
+	r.NameThread(0, 0, "worker 0")
+	r.NameThread(0, 2, "Inspect 2 (parse)")
+
+	start := r.origin.Add(10 * time.Microsecond)
+	r.Record(Span{
+		Name:     "OnRecv",
+		Worker:   0,
+		Vertex:   2,
+		Start:    start,
+		End:      start.Add(5 * time.Microsecond),
+		Ts:       timestamp.NewTimestamp(),
+		Messages: 3,
+	})
+	r.Record(Span{
+		Name:     "Deliver",
+		Worker:   0,
+		Start:    start,
+		End:      start.Add(time.Microsecond),
+		Messages: 1,
+	})
+
+	var buf bytes.Buffer
+	assert.Nil(t, r.WriteJSON(&buf))
+	var res Trace
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, "ms", res.DisplayTimeUnit)
+	assert.Equal(t, []Event{
+		{Name: "process_name", Ph: "M", Pid: 0, Tid: 0, Args: map[string]interface{}{"name": "worker 0"}},
+		{Name: "thread_name", Ph: "M", Pid: 0, Tid: 0, Args: map[string]interface{}{"name": "worker 0"}},
+		{Name: "thread_name", Ph: "M", Pid: 0, Tid: 2, Args: map[string]interface{}{"name": "Inspect 2 (parse)"}},
+		{Name: "OnRecv", Cat: "operator", Ph: "X", Ts: 10, Dur: 5, Pid: 0, Tid: 2,
+			Args: map[string]interface{}{"messages": float64(3), "timestamp": timestamp.NewTimestamp().ToString()}},
+		{Name: "Deliver", Cat: "worker", Ph: "X", Ts: 10, Dur: 1, Pid: 0, Tid: 0,
+			Args: map[string]interface{}{"messages": float64(1)}},
+	}, res.TraceEvents)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", Path, nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, buf.String(), rec.Body.String())
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	assert.False(t, r.Enabled())
+	r.Record(Span{Name: "OnRecv"})
+	r.NameThread(0, 1, "Input 1")
+	assert.Equal(t, []Span{}, r.Spans())
+	assert.Equal(t, []Event{}, r.Trace().TraceEvents)
+}
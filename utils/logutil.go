@@ -1,23 +1,41 @@
 package utils
 
 import (
-	"os"
+	"sync"
 
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
-var logger *zap.Logger = nil
+var (
+	loggerMu sync.Mutex
+	logger   *zap.Logger = nil
+)
 
+// InitLogger sets the default logger, which logs warnings and errors as JSON to stderr.
 func InitLogger() {
-	config := zap.NewDevelopmentEncoderConfig()
-	encoder := zapcore.NewConsoleEncoder(config)
-	logger = zap.New(zapcore.NewCore(encoder, os.Stdout, zapcore.DebugLevel))
+	config := zap.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	l, err := config.Build()
+	if err != nil {
+		l = zap.NewNop()
+	}
+	SetLogger(l)
+}
+
+// SetLogger replaces the default logger, which is used where no logger is configured.
+func SetLogger(l *zap.Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = l
 }
 
 func Logger() *zap.Logger {
-	if logger == nil {
+	loggerMu.Lock()
+	l := logger
+	loggerMu.Unlock()
+	if l == nil {
 		InitLogger()
+		return Logger()
 	}
-	return logger
+	return l
 }
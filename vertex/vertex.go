@@ -1,6 +1,7 @@
 package vertex
 
 import (
+	"fmt"
 	"sync"
 )
 
@@ -19,14 +20,73 @@ const (
 	Type_Feedback
 	Type_Inspect
 	Type_Bianry
+	Type_Sort
+	Type_TopK
+	Type_Branch
+	Type_Iterate
+	Type_Exchange
+	Type_Fused
+	Type_Probe
+	Type_Filter
 )
 
+var typeNames = map[Type]string{
+	Type_Input:          "Input",
+	Type_Ingress:        "Ingress",
+	Type_IngressAdapter: "IngressAdapter",
+	Type_Egress:         "Egress",
+	Type_EgressAdapter:  "EgressAdapter",
+	Type_Feedback:       "Feedback",
+	Type_Inspect:        "Inspect",
+	Type_Bianry:         "Binary",
+	Type_Sort:           "Sort",
+	Type_TopK:           "TopK",
+	Type_Branch:         "Branch",
+	Type_Iterate:        "Iterate",
+	Type_Exchange:       "Exchange",
+	Type_Fused:          "Fused",
+	Type_Probe:          "Probe",
+	Type_Filter:         "Filter",
+}
+
+func (t Type) String() string {
+	if name, exist := typeNames[t]; exist {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
 // Vertex is the interface that represents a vertex in the computing graph.
 type Vertex interface {
 	// Get Id of the vertex.
 	Id() Id
 	// Get Type of the vertex.
 	Type() Type
+	// Label returns the name given to the vertex when it was built, or empty if none.
+	Label() string
 	// Start starts the vertex.
 	Start(wg *sync.WaitGroup) error
+	// Schedule handles at most one pending request of the vertex without blocking,
+	// and tells whether there was any. It is used instead of Start when the worker
+	// steps all vertices in a single goroutine.
+	Schedule() (bool, error)
+}
+
+// Fusable is implemented by vertices with a single input and a single output
+// which keep no state between messages, so that a chain of them can run as one vertex.
+type Fusable interface {
+	Vertex
+	// Fuse returns a vertex doing the work of this vertex and then the work of next,
+	// with the id and input of this vertex and the output of next.
+	// It returns false if next cannot be fused into this vertex.
+	Fuse(next Vertex) (Vertex, bool)
+}
+
+// Describe returns a description of the vertex for logs and errors,
+// such as "Inspect 3", or "Inspect 3 (parse)" if the vertex has a label.
+func Describe(v Vertex) string {
+	if label := v.Label(); label != "" {
+		return fmt.Sprintf("%s %d (%s)", v.Type(), v.Id(), label)
+	}
+	return fmt.Sprintf("%s %d", v.Type(), v.Id())
 }
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/events"
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/metrics"
+	"github.com/stepneko/neko-dataflow/tracing"
+	"go.uber.org/zap"
+)
+
+// Scheduler decides how the vertices of a worker are run.
+type Scheduler int
+
+const (
+	// Every vertex runs in its own goroutine.
+	Scheduler_Threaded Scheduler = iota
+	// All vertices of a worker are stepped in one goroutine, see NewCooperativeWorker.
+	Scheduler_Cooperative
+)
+
+// Config describes how many workers execute a dataflow in this process.
+type Config struct {
+	// Number of workers. Each worker builds its own copy of the dataflow
+	// and runs it in its own goroutines.
+	Workers int
+	// How the vertices of each worker are run.
+	Scheduler Scheduler
+	// Whether chains of stateless single input operators run as one vertex, see SimpleWorker.Fuse.
+	// Fused operators save a hop per message, but report their metrics, events
+	// and spans as the one vertex of the chain.
+	Fusion bool
+	// Registry all workers and operators report their metrics into. Metrics are disabled if nil.
+	Metrics *metrics.Registry
+	// Log all workers and operators emit their events to. Events are disabled if nil.
+	Events *events.Log
+	// Recorder all workers and operators record spans of their work into. Tracing is disabled if nil.
+	Tracer *tracing.Recorder
+	// Logger of all workers and operators. If nil, the default logger of utils is used,
+	// which only logs warnings and errors.
+	Logger *zap.Logger
+	// Address of the debug HTTP server, which serves the DebugState of the dataflow
+	// as JSON at DebugPath, and the trace of Tracer at tracing.Path. The server is not started if empty.
+	DebugAddr string
+	// Period after which the watchdog reports a dataflow which makes no progress, see Watchdog.
+	// The watchdog is not started if 0.
+	Watchdog time.Duration
+	// Whether the run is stopped with the stall as error once the watchdog reports one.
+	WatchdogAbort bool
+	// Whether the progress tracker verifies its counts after every update, see graph.Tracker.SetCheck.
+	// It makes the dataflow much slower and is only meant for debugging.
+	CheckProgress bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Workers:   1,
+		Scheduler: Scheduler_Threaded,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", c.Workers)
+	}
+	if c.Scheduler != Scheduler_Threaded && c.Scheduler != Scheduler_Cooperative {
+		return fmt.Errorf("invalid scheduler: %d", c.Scheduler)
+	}
+	return nil
+}
+
+// NewWorker creates a worker running its vertices with the scheduler of the config.
+func (c Config) NewWorker(ctx context.Context, id Id, peers int, tracker *graph.Tracker) *SimpleWorker {
+	var w *SimpleWorker
+	if c.Scheduler == Scheduler_Cooperative {
+		w = NewCooperativeWorker(ctx, id, peers, tracker)
+	} else {
+		w = NewSimpleWorkerWithParams(ctx, id, peers, tracker)
+	}
+	w.metrics = c.Metrics
+	w.events = c.Events
+	w.tracer = c.Tracer
+	if c.Logger != nil {
+		w.logger = c.Logger.With(zap.Int("worker", int(id)))
+	}
+	return w
+}
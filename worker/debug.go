@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/stepneko/neko-dataflow/graph"
+	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
+)
+
+// DebugPath is the path the debug server of step.Execute serves the state of the dataflow at.
+const DebugPath = "/debug/dataflow"
+
+// VertexState is the state of a vertex of a worker.
+type VertexState struct {
+	Id   vertex.Id `json:"id"`
+	Type string    `json:"type"`
+	Name string    `json:"name,omitempty"`
+	// Latest timestamp seen by the vertex, nil if the vertex does not keep track of it.
+	Ts *timestamp.Timestamp `json:"ts,omitempty"`
+	// Requests waiting in the channels of the vertex, and the capacity of the channels.
+	Queue    int `json:"queue"`
+	Capacity int `json:"capacity"`
+	// Whether the vertex waits for the worker to ack a request.
+	Blocked bool `json:"blocked,omitempty"`
+}
+
+// full tells whether the channels of the vertex cannot take another request.
+func (vs VertexState) full() bool {
+	return vs.Capacity > 0 && vs.Queue >= vs.Capacity
+}
+
+// WorkerState is the state of all vertices of a worker.
+type WorkerState struct {
+	Index int `json:"index"`
+	// Requests from vertices and other workers waiting in the inbox of the worker,
+	// and the capacity of the inbox.
+	Inbox         int `json:"inbox"`
+	InboxCapacity int `json:"inbox_capacity"`
+	// Whether the worker waits for room in the channel of a vertex.
+	Blocked  bool          `json:"blocked,omitempty"`
+	Vertices []VertexState `json:"vertices"`
+}
+
+// inboxFull tells whether the inbox of the worker cannot take another request.
+func (ws WorkerState) inboxFull() bool {
+	return ws.InboxCapacity > 0 && ws.Inbox >= ws.InboxCapacity
+}
+
+// DebugState is the state of a dataflow in a process, to find out why it stalls.
+// The topology and active pointstamps are shared by all workers.
+type DebugState struct {
+	Vertices    []graph.VertexInfo     `json:"vertices"`
+	Pointstamps []graph.PointstampInfo `json:"pointstamps"`
+	Workers     []WorkerState          `json:"workers"`
+}
+
+// blocking is implemented by vertices which can tell whether they wait for the worker.
+type blocking interface {
+	Blocked() bool
+}
+
+// timestamped is implemented by vertices keeping track of the latest timestamp they have seen.
+type timestamped interface {
+	CurrentTimestamp() timestamp.Timestamp
+}
+
+// State returns the state of all vertices of the worker, ordered by id.
+// It can be called while the worker runs.
+func (w *SimpleWorker) State() WorkerState {
+	res := WorkerState{
+		Index:         int(w.id),
+		Inbox:         len(w.inbox.Recv()),
+		InboxCapacity: cap(w.inbox.Recv()),
+		Blocked:       atomic.LoadInt32(&w.blocked) == 1,
+		Vertices:      []VertexState{},
+	}
+	for _, vid := range w.sortedVids() {
+		v := w.vertices[vid]
+		state := VertexState{
+			Id:   vid,
+			Type: v.Type().String(),
+			Name: v.Label(),
+		}
+		if bv, ok := v.(blocking); ok {
+			state.Blocked = bv.Blocked()
+		}
+		if tv, ok := v.(timestamped); ok {
+			ts := tv.CurrentTimestamp()
+			state.Ts = &ts
+		}
+		for _, h := range w.queues(vid) {
+			state.Queue += len(h.MsgRecv())
+			state.Capacity += cap(h.MsgRecv())
+		}
+		res.Vertices = append(res.Vertices, state)
+	}
+	return res
+}
+
+// NewDebugHandler returns an HTTP handler serving the DebugState of the workers as JSON.
+func NewDebugHandler(tracker *graph.Tracker, workers []*SimpleWorker) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		state := DebugState{
+			Vertices:    tracker.Vertices(),
+			Pointstamps: tracker.Pointstamps(),
+			Workers:     []WorkerState{},
+		}
+		for _, w := range workers {
+			state.Workers = append(state.Workers, w.State())
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(rw)
+		enc.SetIndent("", "  ")
+		enc.Encode(&state)
+	})
+}
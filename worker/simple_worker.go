@@ -4,40 +4,130 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/events"
 	"github.com/stepneko/neko-dataflow/graph"
 	"github.com/stepneko/neko-dataflow/handles"
+	"github.com/stepneko/neko-dataflow/metrics"
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/tracing"
 	"github.com/stepneko/neko-dataflow/utils"
 	"github.com/stepneko/neko-dataflow/vertex"
+	"go.uber.org/zap"
+)
+
+// scoped is implemented by vertices which know the scope they are built in.
+type scoped interface {
+	AsScope() scope.Scope
+}
+
+// A cooperative worker with nothing to do checks its inputs again after idleInterval,
+// doubling the wait every time it still has nothing to do, up to maxIdleInterval.
+const (
+	idleInterval    = time.Millisecond
+	maxIdleInterval = 16 * time.Millisecond
 )
 
 type SimpleWorker struct {
-	ctx        context.Context
+	ctx context.Context
+	// Stops the vertices of the worker once it fails.
+	cancelFunc context.CancelFunc
 	id         Id
+	peers      int
 	vidFactory utils.IdFactory
-	graph      *graph.Graph
+	tracker    *graph.Tracker
+	progressCh <-chan struct{}
 	handle     handles.WorkerHandle
-	vHandles   map[vertex.Id]map[vertex.Id]handles.VertexHandle
-	vertices   map[vertex.Id]vertex.Vertex
+	// Handle for requests from other workers. It is the same as handle
+	// unless the worker is cooperative.
+	inbox handles.WorkerHandle
+	// Whether all vertices are stepped in one goroutine, see NewCooperativeWorker.
+	cooperative bool
+	// Requests for vertices whose channel is full, only used by cooperative workers.
+	backlog map[handles.VertexHandle][]request.Request
+	// First error of a request a vertex of a cooperative worker sent, which stops the worker.
+	failure error
+	// 1 while the worker waits for room in the channel of a vertex, read by the watchdog.
+	blocked int32
+	// Handles of all workers running the same dataflow, indexed by worker id.
+	peerHandles []handles.WorkerHandle
+	vHandles    map[vertex.Id]map[vertex.Id]handles.VertexHandle
+	vertices    map[vertex.Id]vertex.Vertex
+	// Handles of each vertex by input port. The handle the vertex is registered with is port 0.
+	inputs map[vertex.Id][]handles.VertexHandle
+	// Notifications requested by vertices via NotifyAt that are not delivered yet.
+	notifications []*graph.VertexPointStamp
+	// Registry the worker and its vertices report into, nil if metrics are disabled.
+	metrics *metrics.Registry
+	// Log the worker and its vertices emit events to, nil if events are disabled.
+	events *events.Log
+	// Recorder the worker and its vertices record spans into, nil if tracing is disabled.
+	tracer *tracing.Recorder
+	logger *zap.Logger
+	// Counters of handled requests by type, created when the worker runs.
+	requestCounters map[request.Type]*metrics.Counter
+	delivered       *metrics.Counter
 }
 
 func NewSimpleWorker(ctx context.Context) *SimpleWorker {
+	return NewSimpleWorkerWithParams(ctx, 0, 1, graph.NewTracker(1))
+}
+
+// NewSimpleWorkerWithParams creates the worker with index id out of peers workers
+// in the process. All the workers share the same progress tracker.
+func NewSimpleWorkerWithParams(
+	ctx context.Context,
+	id Id,
+	peers int,
+	tracker *graph.Tracker,
+) *SimpleWorker {
+	ctx, cancelFunc := context.WithCancel(ctx)
+	handle := handles.NewSimpleWorkerHandleWithDone(ctx.Done())
 	return &SimpleWorker{
 		ctx:        ctx,
-		id:         0,
+		cancelFunc: cancelFunc,
+		id:         id,
+		peers:      peers,
 		vidFactory: utils.NewSimpleIdFactory(),
-		graph:      graph.NewGraph(),
-		handle:     handles.NewSimpleWorkerHandle(),
+		tracker:    tracker,
+		progressCh: tracker.Subscribe(),
+		handle:     handle,
+		inbox:      handle,
+		backlog:    make(map[handles.VertexHandle][]request.Request),
+		logger:     utils.Logger().With(zap.Int("worker", int(id))),
 		vHandles:   make(map[vertex.Id]map[vertex.Id]handles.VertexHandle),
 		vertices:   make(map[vertex.Id]vertex.Vertex),
+		inputs:     make(map[vertex.Id][]handles.VertexHandle),
+
+		notifications: []*graph.VertexPointStamp{},
 	}
 }
 
+// NewCooperativeWorker creates a worker which steps all its vertices in one goroutine
+// instead of running each of them in its own goroutine. Vertices are stepped in the order
+// of their ids, and requests of a vertex to the worker are handled at once,
+// so a run with the same input always executes in the same order.
+func NewCooperativeWorker(
+	ctx context.Context,
+	id Id,
+	peers int,
+	tracker *graph.Tracker,
+) *SimpleWorker {
+	w := NewSimpleWorkerWithParams(ctx, id, peers, tracker)
+	w.cooperative = true
+	w.handle = handles.NewSyncWorkerHandle(w.handleSync)
+	return w
+}
+
 //============== Impl Worker interface ================//
 
 // Dataflow builds the dataflow as described in the function fn.
@@ -58,7 +148,16 @@ func (w *SimpleWorker) ToScope() scope.Scope {
 
 func (w *SimpleWorker) Run() error {
 
-	w.graph.PreProcess()
+	if err := w.tracker.PreProcess(); err != nil {
+		return err
+	}
+
+	w.registerMetrics()
+	w.nameThreads()
+
+	if w.cooperative {
+		return w.runCooperative()
+	}
 
 	var wg sync.WaitGroup
 	for id := range w.vertices {
@@ -67,11 +166,101 @@ func (w *SimpleWorker) Run() error {
 		go v.Start(&wg)
 	}
 
-	wg.Add(1)
-	go w.serve(&wg)
+	serveErr := make(chan error, 1)
+	go func() {
+		err := w.serve()
+		if err != nil {
+			// The dataflow cannot make progress without the worker, so its vertices stop as well.
+			w.cancelFunc()
+		}
+		serveErr <- err
+	}()
 
 	wg.Wait()
 
+	return <-serveErr
+}
+
+// Fuse merges every chain of fusable vertices into one vertex, so that a message
+// goes through the whole chain without being sent from vertex to vertex.
+// A vertex is only fused with the next one if it is the only upstream of it
+// and the next one is its only downstream. Fuse is called after the dataflow is built
+// and before the worker runs.
+func (w *SimpleWorker) Fuse() error {
+	for _, vid := range w.sortedVids() {
+		for {
+			v, ok := w.vertices[vid].(vertex.Fusable)
+			if !ok {
+				break
+			}
+			next, ok := w.fusionTarget(vid)
+			if !ok {
+				break
+			}
+			fused, ok := v.Fuse(w.vertices[next])
+			if !ok {
+				break
+			}
+			if err := w.tracker.Fuse(vid, next, fused.Type()); err != nil {
+				return err
+			}
+			if err := w.tracker.SetName(vid, fused.Label()); err != nil {
+				return err
+			}
+			w.emit(events.Event{
+				Kind:   events.Kind_OperatorFused,
+				Vertex: next,
+				Src:    vid,
+				Target: next,
+			})
+			w.vertices[vid] = fused
+			delete(w.vertices, next)
+			delete(w.vHandles[vid], next)
+			for target, handle := range w.vHandles[next] {
+				if target != next {
+					w.vHandles[vid][target] = handle
+				}
+			}
+			delete(w.vHandles, next)
+			delete(w.inputs, next)
+		}
+	}
+	return nil
+}
+
+// ExportDOT writes the dataflow graph in the DOT language of Graphviz.
+// The graph is shared by all workers of the process, see graph.Graph.ExportDOT.
+func (w *SimpleWorker) ExportDOT(out io.Writer) error {
+	return w.tracker.ExportDOT(out)
+}
+
+// ExportJSON writes the dataflow graph as JSON, see graph.Graph.ExportJSON.
+func (w *SimpleWorker) ExportJSON(out io.Writer) error {
+	return w.tracker.ExportJSON(out)
+}
+
+// Index returns the index of the worker among its peers.
+func (w *SimpleWorker) Index() int {
+	return int(w.id)
+}
+
+// Peers returns the number of workers running the same dataflow.
+func (w *SimpleWorker) Peers() int {
+	return w.peers
+}
+
+// InboxHandle returns the handle other workers send requests to.
+func (w *SimpleWorker) InboxHandle() handles.WorkerHandle {
+	return w.inbox
+}
+
+// ConnectPeers sets the handles of all workers running the same dataflow,
+// indexed by worker id, so that messages can be exchanged between workers.
+func (w *SimpleWorker) ConnectPeers(peerHandles []handles.WorkerHandle) error {
+	if len(peerHandles) != w.peers {
+		return fmt.Errorf("expect %d peer handles but got %d", w.peers, len(peerHandles))
+	}
+	w.peerHandles = peerHandles
 	return nil
 }
 
@@ -80,6 +269,11 @@ func (w *SimpleWorker) Name() string {
 	return fmt.Sprintf("worker %d", w.id)
 }
 
+// Depth of the worker scope is 0 because it is the outermost scope.
+func (w *SimpleWorker) Depth() int {
+	return 0
+}
+
 func (w *SimpleWorker) GenerateVID() vertex.Id {
 	return w.vidFactory.Generate()
 }
@@ -100,9 +294,26 @@ func (w *SimpleWorker) RegisterVertex(
 	w.vertices[vid] = v
 
 	w.setHandle(vid, vid, handle)
+	w.inputs[vid] = []handles.VertexHandle{handle}
 
 	// Insert the vertex into scheduler
-	w.graph.InsertVertex(vid, v.Type())
+	w.tracker.InsertVertex(vid, v.Type())
+	if sv, ok := v.(scoped); ok {
+		if err := w.tracker.SetScope(vid, scope.Path(sv.AsScope())); err != nil {
+			return err
+		}
+	}
+	if label := v.Label(); label != "" {
+		if err := w.tracker.SetName(vid, label); err != nil {
+			return err
+		}
+	}
+	w.emit(events.Event{
+		Kind:   events.Kind_OperatorCreated,
+		Vertex: vid,
+		Type:   v.Type().String(),
+		Name:   v.Label(),
+	})
 	return nil
 }
 
@@ -123,12 +334,52 @@ func (w *SimpleWorker) RegisterEdge(
 
 	w.setHandle(srcId, targetId, handle)
 
-	e := edge.NewEdge(srcId, targetId)
-	w.graph.InsertEdge(e)
+	port := 0
+	for port < len(w.inputs[targetId]) && w.inputs[targetId][port] != handle {
+		port++
+	}
+	if port == len(w.inputs[targetId]) {
+		w.inputs[targetId] = append(w.inputs[targetId], handle)
+	}
 
+	e := edge.NewEdge(srcId, targetId)
+	if err := w.tracker.InsertEdgeToPort(e, port); err != nil {
+		return err
+	}
+	w.emit(events.Event{
+		Kind:   events.Kind_EdgeCreated,
+		Src:    srcId,
+		Target: targetId,
+	})
 	return nil
 }
 
+func (w *SimpleWorker) GetPeerHandle(index int) (handles.WorkerHandle, error) {
+	if index == int(w.id) {
+		return w.handle, nil
+	}
+	if index < 0 || index >= len(w.peerHandles) {
+		return nil, fmt.Errorf("peer not connected with index %d", index)
+	}
+	return w.peerHandles[index], nil
+}
+
+func (w *SimpleWorker) Metrics() *metrics.Registry {
+	return w.metrics
+}
+
+func (w *SimpleWorker) Logger() *zap.Logger {
+	return w.logger
+}
+
+func (w *SimpleWorker) Events() *events.Log {
+	return w.events
+}
+
+func (w *SimpleWorker) Tracer() *tracing.Recorder {
+	return w.tracer
+}
+
 func (w *SimpleWorker) Done() <-chan struct{} {
 	return w.ctx.Done()
 }
@@ -144,11 +395,19 @@ func (w *SimpleWorker) getHandle(
 	}
 	h, exist := m[target]
 	if !exist {
-		return nil, fmt.Errorf("cannot find handle because target not found with id %d", target)
+		return nil, fmt.Errorf("cannot find handle of %s because target not found with id %d", w.describe(src), target)
 	}
 	return h, nil
 }
 
+// describe returns a description of a vertex for errors, see vertex.Describe.
+func (w *SimpleWorker) describe(vid vertex.Id) string {
+	if v, exist := w.vertices[vid]; exist {
+		return vertex.Describe(v)
+	}
+	return fmt.Sprintf("vertex %d", vid)
+}
+
 func (w *SimpleWorker) setHandle(
 	src vertex.Id,
 	target vertex.Id,
@@ -161,8 +420,22 @@ func (w *SimpleWorker) setHandle(
 	w.vHandles[src][target] = handle
 }
 
+// handleSync handles a request a vertex of a cooperative worker sent. The error goes back
+// to the vertex, and is kept to stop the worker, as it does for a worker serving requests.
+func (w *SimpleWorker) handleSync(req *request.Request) error {
+	err := w.handleReq(req)
+	if err != nil && w.failure == nil {
+		w.failure = err
+	}
+	return err
+}
+
 func (w *SimpleWorker) handleReq(req *request.Request) error {
 	typ := req.Type
+	w.requestCounters[typ].Inc()
+	if w.tracer.Enabled() {
+		defer w.trace(typ.String(), &req.Ts, time.Now(), 1)
+	}
 
 	if typ == request.Type_IncreOC {
 		return w.increOC(req)
@@ -176,8 +449,9 @@ func (w *SimpleWorker) handleReq(req *request.Request) error {
 	return nil
 }
 
-func (w *SimpleWorker) serve(wg *sync.WaitGroup) error {
-	defer wg.Done()
+// serve handles the requests of the vertices and other workers until the worker is done.
+// It returns the first error of a request, as the worker cannot go on without it.
+func (w *SimpleWorker) serve() error {
 	ch := w.handle.Recv()
 	for {
 		select {
@@ -187,6 +461,11 @@ func (w *SimpleWorker) serve(wg *sync.WaitGroup) error {
 			if err := w.handleReq(&req); err != nil {
 				return err
 			}
+		case <-w.progressCh:
+			// Another worker moved the frontier
+			if err := w.deliverNotifications(); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -206,9 +485,10 @@ func (w *SimpleWorker) increOC(req *request.Request) error {
 			&ts,
 		)
 	}
-	if err := w.graph.IncreOC(ps); err != nil {
+	if err := w.tracker.IncreOC(ps); err != nil {
 		return err
 	}
+	w.emitPointstamp(events.Kind_PointstampIncremented, e, &ts)
 	vid := e.GetSrc()
 	vHandle, err := w.getHandle(vid, vid)
 	if err != nil {
@@ -239,9 +519,10 @@ func (w *SimpleWorker) decreOC(req *request.Request) error {
 			&ts,
 		)
 	}
-	if err := w.graph.DecreOC(ps); err != nil {
+	if err := w.tracker.DecreOC(ps); err != nil {
 		return err
 	}
+	w.emitPointstamp(events.Kind_PointstampDecremented, e, &ts)
 	vid := e.GetTarget()
 	vHandle, err := w.getHandle(vid, vid)
 	if err != nil {
@@ -254,7 +535,10 @@ func (w *SimpleWorker) decreOC(req *request.Request) error {
 		Msg:  request.Message{},
 	}
 	vHandle.Ack(&newReq)
-	return nil
+
+	// Removing a pointstamp may move the frontier forward,
+	// so check whether any notification could be delivered now.
+	return w.deliverNotifications()
 }
 
 func (w *SimpleWorker) sendBy(req *request.Request) error {
@@ -271,23 +555,377 @@ func (w *SimpleWorker) sendBy(req *request.Request) error {
 		Ts:   req.Ts,
 		Msg:  req.Msg,
 	}
-	vHandle.Send(&newReq)
+	w.send(vHandle, &newReq)
+	w.emit(events.Event{
+		Kind:   events.Kind_MessageSent,
+		Src:    src,
+		Target: target,
+		Ts:     &req.Ts,
+	})
 	return nil
 }
 
+// notifyAt registers a notification request from a vertex.
+// According to the paper, the pointstamp of the notification is added
+// to the active set, so that the notification also holds back the
+// frontier for anything it could-result-in. The vertex removes it with
+// a DecreOC after handling the notification.
 func (w *SimpleWorker) notifyAt(req *request.Request) error {
 	e := req.Edge
-	target := e.GetTarget()
-	vHandle, err := w.getHandle(target, target)
+	vid := e.GetTarget()
+	vHandle, err := w.getHandle(vid, vid)
 	if err != nil {
 		return err
 	}
+	ts := timestamp.CopyTimestampFrom(&req.Ts)
+	ps := graph.NewVertexPointStamp(vid, ts)
+	if err := w.tracker.IncreOC(ps); err != nil {
+		return err
+	}
+	w.emitPointstamp(events.Kind_PointstampIncremented, e, ts)
+	w.notifications = append(w.notifications, ps)
+
 	newReq := request.Request{
-		Type: request.Type_OnNotify,
-		Edge: e,
-		Ts:   req.Ts,
-		Msg:  req.Msg,
+		Type: request.Type_Ack,
+		Edge: nil,
+		Ts:   timestamp.Timestamp{},
+		Msg:  request.Message{},
+	}
+	vHandle.Ack(&newReq)
+
+	return w.deliverNotifications()
+}
+
+// deliverNotifications sends OnNotify to every vertex whose requested
+// notification is in the frontier, which means no other active pointstamp
+// could-result-in the pointstamp of the notification.
+func (w *SimpleWorker) deliverNotifications() error {
+	if w.tracer.Enabled() {
+		start, before := time.Now(), len(w.notifications)
+		defer func() {
+			if delivered := before - len(w.notifications); delivered > 0 {
+				w.trace("Deliver", nil, start, delivered)
+			}
+		}()
+	}
+	pending := []*graph.VertexPointStamp{}
+	for _, ps := range w.notifications {
+		ready, err := w.tracker.InFrontier(ps)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			pending = append(pending, ps)
+			continue
+		}
+		vid := ps.GetSrc()
+		vHandle, err := w.getHandle(vid, vid)
+		if err != nil {
+			return err
+		}
+		newReq := request.Request{
+			Type: request.Type_OnNotify,
+			Edge: edge.NewEdge(vid, vid),
+			Ts:   *ps.GetTimestamp(),
+			Msg:  request.Message{},
+		}
+		w.send(vHandle, &newReq)
+		w.delivered.Inc()
+		w.emit(events.Event{
+			Kind:   events.Kind_NotificationDelivered,
+			Vertex: vid,
+			Ts:     ps.GetTimestamp(),
+		})
 	}
-	vHandle.Send(&newReq)
+	w.notifications = pending
 	return nil
 }
+
+// trace records a span of the worker which started at start and ends now.
+func (w *SimpleWorker) trace(name string, ts *timestamp.Timestamp, start time.Time, messages int) {
+	var tsCopy *timestamp.Timestamp
+	if ts != nil {
+		tsCopy = timestamp.CopyTimestampFrom(ts)
+	}
+	w.tracer.Record(tracing.Span{
+		Name:     name,
+		Worker:   int(w.id),
+		Vertex:   vertex.Id_Nil,
+		Start:    start,
+		End:      time.Now(),
+		Ts:       tsCopy,
+		Messages: messages,
+	})
+}
+
+// nameThreads names the threads of the worker and its vertices in the trace.
+// It is called once the dataflow is built and fused.
+func (w *SimpleWorker) nameThreads() {
+	if !w.tracer.Enabled() {
+		return
+	}
+	w.tracer.NameThread(int(w.id), vertex.Id_Nil, w.Name())
+	for _, v := range w.vertices {
+		w.tracer.NameThread(int(w.id), v.Id(), vertex.Describe(v))
+	}
+}
+
+// emit sends an event of the worker to the event log.
+func (w *SimpleWorker) emit(e events.Event) {
+	if !w.events.Enabled() {
+		return
+	}
+	e.Worker = int(w.id)
+	if e.Ts != nil {
+		e.Ts = timestamp.CopyTimestampFrom(e.Ts)
+	}
+	w.events.Emit(e)
+}
+
+// emitPointstamp sends an event about the pointstamp of edge e, or of its vertex
+// if e is a self edge, to the event log.
+func (w *SimpleWorker) emitPointstamp(kind events.Kind, e edge.Edge, ts *timestamp.Timestamp) {
+	event := events.Event{
+		Kind:   kind,
+		Src:    e.GetSrc(),
+		Target: e.GetTarget(),
+		Ts:     ts,
+	}
+	if e.GetSrc() == e.GetTarget() {
+		event.Vertex = e.GetSrc()
+	}
+	w.emit(event)
+}
+
+// registerMetrics creates the metrics of the worker, and gauges for the queue length of
+// every vertex. It is called once the dataflow is built and fused.
+func (w *SimpleWorker) registerMetrics() {
+	r := w.metrics
+	labels := metrics.Labels{"worker": strconv.Itoa(int(w.id))}
+
+	w.requestCounters = make(map[request.Type]*metrics.Counter)
+	for _, typ := range []request.Type{
+		request.Type_SendBy,
+		request.Type_NotifyAt,
+		request.Type_IncreOC,
+		request.Type_DecreOC,
+	} {
+		w.requestCounters[typ] = r.Counter(
+			"neko_worker_requests_total",
+			"Number of requests handled by the worker.",
+			metrics.Labels{"worker": labels["worker"], "type": typ.String()},
+		)
+	}
+	w.delivered = r.Counter(
+		"neko_worker_notifications_delivered_total",
+		"Number of notifications delivered to vertices by the worker.",
+		labels,
+	)
+	r.GaugeFunc(
+		"neko_tracker_active_pointstamps",
+		"Number of active pointstamps in the progress tracker of the process.",
+		nil,
+		func() float64 { return float64(w.tracker.ActivePointstamps()) },
+	)
+
+	for vid, v := range w.vertices {
+		queues := w.queues(vid)
+		vLabels := metrics.Labels{
+			"worker": labels["worker"],
+			"vertex": strconv.Itoa(int(vid)),
+			"type":   v.Type().String(),
+		}
+		if label := v.Label(); label != "" {
+			vLabels["name"] = label
+		}
+		r.GaugeFunc(
+			"neko_operator_queue_length",
+			"Number of requests waiting in the channels of the vertex.",
+			vLabels,
+			func() float64 {
+				n := 0
+				for _, h := range queues {
+					n += len(h.MsgRecv())
+				}
+				return float64(n)
+			},
+		)
+	}
+}
+
+// queues returns the handles whose channels hold requests for the vertex.
+// A vertex with several inputs has a handle for each of them.
+func (w *SimpleWorker) queues(vid vertex.Id) []handles.VertexHandle {
+	return w.inputs[vid]
+}
+
+// sortedVids returns the ids of all vertices in ascending order.
+func (w *SimpleWorker) sortedVids() []vertex.Id {
+	vids := []vertex.Id{}
+	for vid := range w.vertices {
+		vids = append(vids, vid)
+	}
+	sort.Slice(vids, func(i, j int) bool { return vids[i] < vids[j] })
+	return vids
+}
+
+// fusionTarget returns the only downstream of vertex vid,
+// if vid is also the only upstream of it.
+func (w *SimpleWorker) fusionTarget(vid vertex.Id) (vertex.Id, bool) {
+	next := vertex.Id(vertex.Id_Nil)
+	for target := range w.vHandles[vid] {
+		if target == vid {
+			continue
+		}
+		if next != vertex.Id_Nil {
+			return vertex.Id_Nil, false
+		}
+		next = target
+	}
+	if next == vertex.Id_Nil {
+		return vertex.Id_Nil, false
+	}
+	for src, targets := range w.vHandles {
+		if src == vid || src == next {
+			continue
+		}
+		if _, exist := targets[next]; exist {
+			return vertex.Id_Nil, false
+		}
+	}
+	return next, true
+}
+
+// send delivers a request to a vertex. A cooperative worker runs in the goroutine
+// of the vertex, so instead of blocking on a full channel it keeps the request
+// in the backlog until the vertex has made room. Otherwise the worker waits for room
+// until it is done, as a stalled vertex may never make any.
+func (w *SimpleWorker) send(vHandle handles.VertexHandle, req *request.Request) {
+	if !w.cooperative {
+		atomic.StoreInt32(&w.blocked, 1)
+		defer atomic.StoreInt32(&w.blocked, 0)
+		select {
+		case vHandle.MsgRecv() <- *req:
+		case <-w.ctx.Done():
+		}
+		return
+	}
+	if len(w.backlog[vHandle]) == 0 {
+		select {
+		case vHandle.MsgRecv() <- *req:
+			return
+		default:
+		}
+	}
+	w.backlog[vHandle] = append(w.backlog[vHandle], *req)
+}
+
+// flushBacklog moves requests from the backlog to the channel of the vertex while there is room.
+// It tells whether any request was moved.
+func (w *SimpleWorker) flushBacklog(vHandle handles.VertexHandle) bool {
+	reqs := w.backlog[vHandle]
+	n := len(reqs)
+	for len(reqs) > 0 {
+		select {
+		case vHandle.MsgRecv() <- reqs[0]:
+			reqs = reqs[1:]
+			continue
+		default:
+		}
+		break
+	}
+	if len(reqs) == 0 {
+		delete(w.backlog, vHandle)
+	} else {
+		w.backlog[vHandle] = reqs
+	}
+	return len(reqs) != n
+}
+
+// runCooperative steps all vertices in the order of their ids until the worker is done.
+// When no vertex has anything to do, it waits for requests from other workers,
+// progress made by other workers, or new input. Inputs cannot wake the worker up,
+// so it checks them again after a wait which grows as long as there is nothing to do.
+func (w *SimpleWorker) runCooperative() error {
+	vids := w.sortedVids()
+
+	idle := idleInterval
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		busy := false
+
+		for done := false; !done; {
+			select {
+			case <-w.ctx.Done():
+				return nil
+			case req := <-w.inbox.Recv():
+				if err := w.handleReq(&req); err != nil {
+					return err
+				}
+				busy = true
+			case <-w.progressCh:
+				if err := w.deliverNotifications(); err != nil {
+					return err
+				}
+			default:
+				done = true
+			}
+		}
+
+		for _, vid := range vids {
+			for _, vHandle := range w.vHandles[vid] {
+				// Requests moved out of the backlog are handled by the next round at the latest.
+				busy = w.flushBacklog(vHandle) || busy
+			}
+			ok, err := w.vertices[vid].Schedule()
+			if w.failure != nil {
+				return w.failure
+			}
+			if err != nil {
+				fields := []zap.Field{
+					zap.Int("vertex", int(vid)),
+					zap.String("type", w.vertices[vid].Type().String()),
+				}
+				if label := w.vertices[vid].Label(); label != "" {
+					fields = append(fields, zap.String("name", label))
+				}
+				w.logger.Error(err.Error(), fields...)
+			}
+			busy = busy || ok
+		}
+
+		if busy {
+			idle = idleInterval
+			continue
+		}
+		// Requests left in the backlog wait for a vertex to make room, which only happens
+		// once something else happened, so the worker idles with a backlog as well.
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(idle)
+		select {
+		case <-w.ctx.Done():
+			return nil
+		case req := <-w.inbox.Recv():
+			if err := w.handleReq(&req); err != nil {
+				return err
+			}
+			idle = idleInterval
+		case <-w.progressCh:
+			if err := w.deliverNotifications(); err != nil {
+				return err
+			}
+			idle = idleInterval
+		case <-timer.C:
+			if idle < maxIdleInterval {
+				idle *= 2
+			}
+		}
+	}
+}
@@ -1,16 +1,20 @@
 package worker
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"strconv"
 	"testing"
 
 	"github.com/stepneko/neko-dataflow/edge"
+	"github.com/stepneko/neko-dataflow/graph"
 	"github.com/stepneko/neko-dataflow/iterator"
 	"github.com/stepneko/neko-dataflow/operators"
 	"github.com/stepneko/neko-dataflow/request"
 	"github.com/stepneko/neko-dataflow/scope"
 	"github.com/stepneko/neko-dataflow/timestamp"
+	"github.com/stepneko/neko-dataflow/vertex"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,3 +56,154 @@ func TestSimpleWorker(t *testing.T) {
 		assert.Equal(t, tsCounter, i)
 	}
 }
+
+func TestSimpleWorkerFuse(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	w := NewSimpleWorker(ctx)
+
+	inputCh := make(chan request.InputDatum, 1024)
+	inspectCh := make(chan string, 1024)
+	w.Dataflow(func(s scope.Scope) error {
+		operators.
+			NewInput(s, inputCh).
+			Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+				// Emit every message twice
+				return iterator.IterFromArray([]*request.Message{msg, msg}), nil
+			}).
+			Filter(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+				return msg.ToString() != "1", nil
+			}).
+			SortBy(func(a *request.Message, b *request.Message) bool {
+				return a.ToString() > b.ToString()
+			}).
+			Inspect(func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+				inspectCh <- msg.ToString()
+				return nil, nil
+			})
+		return nil
+	})
+
+	assert.Nil(t, w.Fuse())
+
+	// Input, the fused inspect and filter, sort and the last inspect
+	assert.Equal(t, 4, len(w.vertices))
+	assert.Equal(t, vertex.Type_Fused, w.vertices[2].Type())
+	next, ok := w.fusionTarget(2)
+	assert.True(t, ok)
+	assert.Equal(t, vertex.Type_Sort, w.vertices[next].Type())
+
+	go w.Run()
+
+	for i := 0; i < 3; i++ {
+		inputCh <- request.NewInputRaw(
+			request.NewMessage([]byte(strconv.Itoa(i))),
+			*timestamp.NewTimestamp(),
+		)
+	}
+	close(inputCh)
+
+	res := []string{}
+	for i := 0; i < 4; i++ {
+		res = append(res, <-inspectCh)
+	}
+	assert.Equal(t, []string{"2", "2", "0", "0"}, res)
+}
+
+func TestSimpleWorkerExport(t *testing.T) {
+	w := NewSimpleWorker(context.Background())
+
+	identity := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+		return iterator.IterFromSingleton(msg), nil
+	}
+	never := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+		return false, nil
+	}
+	w.Dataflow(func(s scope.Scope) error {
+		other := operators.NewInput(s, make(chan request.InputDatum))
+		operators.NewInput(s, make(chan request.InputDatum)).
+			Loop(func(ups operators.Operator) operators.Operator {
+				return ups.Loop(func(ups operators.Operator) operators.Operator {
+					return ups.Inspect(identity)
+				}, never)
+			}, never).
+			Concat(other)
+		return nil
+	})
+
+	var buf bytes.Buffer
+	assert.Nil(t, w.ExportJSON(&buf))
+	var res graph.Export
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &res))
+
+	scopes := map[vertex.Id]string{}
+	for _, v := range res.Vertices {
+		scopes[v.Id] = v.Scope
+	}
+	// The egress of a loop belongs to the parent scope
+	assert.Equal(t, map[vertex.Id]string{
+		1: "", 2: "",
+		3: "loop3", 4: "loop3", 10: "loop3", 11: "loop3", 12: "loop3",
+		5: "loop3/loop5", 6: "loop3/loop5", 7: "loop3/loop5", 8: "loop3/loop5", 9: "loop3/loop5",
+		13: "", 14: "",
+	}, scopes)
+
+	ports := map[[2]vertex.Id]int{}
+	for _, e := range res.Edges {
+		if e.Port != 0 {
+			ports[[2]vertex.Id{e.Src, e.Target}] = e.Port
+		}
+	}
+	// Feedback edges and the second input of the binary operator
+	assert.Equal(t, map[[2]vertex.Id]int{{9, 6}: 1, {12, 4}: 1, {1, 14}: 1}, ports)
+
+	buf.Reset()
+	assert.Nil(t, w.ExportDOT(&buf))
+	assert.Contains(t, buf.String(), "\tsubgraph \"cluster_loop3/loop5\" {\n\t\t\tlabel=\"loop5\";\n")
+}
+
+func TestSimpleWorkerNames(t *testing.T) {
+	w := NewSimpleWorker(context.Background())
+
+	identity := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (iterator.Iterator[*request.Message], error) {
+		return iterator.IterFromSingleton(msg), nil
+	}
+	never := func(e edge.Edge, msg *request.Message, ts timestamp.Timestamp) (bool, error) {
+		return false, nil
+	}
+	w.Dataflow(func(s scope.Scope) error {
+		operators.NewInput(s, make(chan request.InputDatum), operators.WithName("source")).
+			Loop(func(ups operators.Operator) operators.Operator {
+				return ups.Inspect(identity, operators.WithName("step"))
+			}, never, operators.WithName("bfs")).
+			Filter(never, operators.WithName("even")).
+			Inspect(identity, operators.WithName("sink"))
+		return nil
+	})
+	assert.Nil(t, w.Fuse())
+
+	var buf bytes.Buffer
+	assert.Nil(t, w.ExportJSON(&buf))
+	var res graph.Export
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &res))
+
+	names := map[vertex.Id]string{}
+	for _, v := range res.Vertices {
+		if v.Name != "" {
+			names[v.Id] = v.Name
+		}
+		if v.Id == 4 {
+			assert.Equal(t, "bfs2", v.Scope)
+		}
+	}
+	// The filter and the inspect after it are fused into one vertex
+	assert.Equal(t, map[vertex.Id]string{1: "source", 4: "step", 8: "even+sink"}, names)
+
+	buf.Reset()
+	assert.Nil(t, w.ExportDOT(&buf))
+	assert.Contains(t, buf.String(), "8 [label=\"8: even+sink (Fused)\"];")
+
+	_, err := w.getHandle(4, 1)
+	assert.EqualError(t, err, "cannot find handle of Inspect 4 (step) because target not found with id 1")
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stepneko/neko-dataflow/graph"
+	"go.uber.org/zap"
+)
+
+// Stall describes a dataflow which made no progress for a while although some of
+// its vertices or workers could not go on, see Watchdog.
+type Stall struct {
+	Period time.Duration `json:"period"`
+	// Workers with the vertices which are blocked or whose channel is full.
+	Workers     []WorkerState          `json:"workers"`
+	Pointstamps []graph.PointstampInfo `json:"pointstamps"`
+}
+
+func (s *Stall) Error() string {
+	blocked := []string{}
+	full := []string{}
+	for _, ws := range s.Workers {
+		if ws.Blocked {
+			blocked = append(blocked, fmt.Sprintf("worker %d", ws.Index))
+		}
+		if ws.inboxFull() {
+			full = append(full, fmt.Sprintf("worker %d inbox", ws.Index))
+		}
+		for _, vs := range ws.Vertices {
+			name := fmt.Sprintf("worker %d %s %d", ws.Index, vs.Type, vs.Id)
+			if vs.Name != "" {
+				name = fmt.Sprintf("%s (%s)", name, vs.Name)
+			}
+			if vs.Blocked {
+				blocked = append(blocked, name)
+			}
+			if vs.full() {
+				full = append(full, name)
+			}
+		}
+	}
+	return fmt.Sprintf(
+		"dataflow made no progress for %v: blocked [%s], full channels [%s], %d active pointstamps",
+		s.Period,
+		strings.Join(blocked, ", "),
+		strings.Join(full, ", "),
+		len(s.Pointstamps),
+	)
+}
+
+// Watchdog reports a dataflow which makes no progress, such as a loop whose feedback
+// vertex waits for an ack while the worker waits for room in the full channel of the loop.
+// The dataflow is stalled if the progress tracker sees no update for a whole period
+// while some vertex or worker is blocked or its channel is full, at both its start and end.
+// Vertices are blocked for a moment whenever they wait for an ack, so one look is not enough.
+// A dataflow waiting for input is idle, and a vertex in a long callback with
+// requests waiting is busy, neither is stalled.
+type Watchdog struct {
+	tracker *graph.Tracker
+	workers []*SimpleWorker
+	period  time.Duration
+	// Whether Run returns once the dataflow stalls, instead of only logging it.
+	abort  bool
+	logger *zap.Logger
+}
+
+func NewWatchdog(
+	tracker *graph.Tracker,
+	workers []*SimpleWorker,
+	period time.Duration,
+	abort bool,
+	logger *zap.Logger,
+) *Watchdog {
+	return &Watchdog{
+		tracker: tracker,
+		workers: workers,
+		period:  period,
+		abort:   abort,
+		logger:  logger,
+	}
+}
+
+// Run checks the dataflow every period until ctx is done. A stall is logged once,
+// and again only after the dataflow made progress in between. If the watchdog aborts,
+// Run returns the first stall as a *Stall error.
+func (d *Watchdog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.period)
+	defer ticker.Stop()
+
+	last := d.tracker.Updates()
+	reported := false
+	// Stall seen at the previous check, to be confirmed by the next one.
+	var suspect *Stall
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		updates := d.tracker.Updates()
+		if updates != last {
+			last = updates
+			reported = false
+			suspect = nil
+			continue
+		}
+		stall := d.Check()
+		if stall == nil || reported {
+			suspect = nil
+			continue
+		}
+		if suspect == nil {
+			suspect = stall
+			continue
+		}
+		reported = true
+		d.logger.Error(stall.Error(), zap.Any("stall", stall))
+		if d.abort {
+			return stall
+		}
+	}
+}
+
+// Check returns the blocked vertices and workers and those with a full channel,
+// with the active pointstamps, or nil if there are none.
+func (d *Watchdog) Check() *Stall {
+	stall := &Stall{
+		Period:  d.period,
+		Workers: []WorkerState{},
+	}
+	for _, w := range d.workers {
+		ws := w.State()
+		vertices := []VertexState{}
+		for _, vs := range ws.Vertices {
+			if vs.Blocked || vs.full() {
+				vertices = append(vertices, vs)
+			}
+		}
+		if len(vertices) == 0 && !ws.Blocked && !ws.inboxFull() {
+			continue
+		}
+		ws.Vertices = vertices
+		stall.Workers = append(stall.Workers, ws)
+	}
+	if len(stall.Workers) == 0 {
+		return nil
+	}
+	stall.Pointstamps = d.tracker.Pointstamps()
+	return stall
+}
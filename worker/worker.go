@@ -14,4 +14,8 @@ type Worker interface {
 	Dataflow(fn DataflowFunc) error
 	ToScope() scope.Scope
 	Run() error
+	// Index returns the index of the worker among its peers.
+	Index() int
+	// Peers returns the number of workers running the same dataflow.
+	Peers() int
 }